@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// renderedFile is the fully rendered output of a template, held in memory until it's committed to disk.
+type renderedFile struct {
+	Path string
+	Data []byte
+}
+
+func newRenderedFile(path string, data *bytes.Buffer) renderedFile {
+	return renderedFile{
+		Path: path,
+		Data: data.Bytes(),
+	}
+}
+
+// commitFiles writes all files or none of them.
+//
+// Each file is written to a temp file next to its destination and then renamed into place, so no file is
+// ever left half written. If any file fails, every file written before it is restored to what was there
+// before the run (or removed, if it didn't exist), so a failed run never breaks a working tree.
+func commitFiles(files []renderedFile) error {
+	tx := &fileTransaction{}
+
+	for _, file := range files {
+		err := tx.write(file)
+		if err != nil {
+			rollbackErr := tx.rollback()
+			if rollbackErr != nil {
+				return fmt.Errorf("%w (rolling back also failed: %s)", err, rollbackErr)
+			}
+			return err
+		}
+	}
+
+	tx.commit()
+	return nil
+}
+
+type writtenFile struct {
+	path string
+	// backupPath holds a copy of the file as it was before we overwrote it, empty if the file is new.
+	backupPath string
+}
+
+type fileTransaction struct {
+	createdDirs  []string
+	writtenFiles []writtenFile
+}
+
+func (tx *fileTransaction) write(file renderedFile) error {
+	dir := filepath.Dir(file.Path)
+	err := tx.mkdirAll(dir)
+	if err != nil {
+		return fmt.Errorf("error creating directory %s: %w", dir, err)
+	}
+
+	tmpPath, err := writeTempFile(dir, filepath.Base(file.Path)+".tmp-*", file.Data)
+	if err != nil {
+		return fmt.Errorf("error writing to file %s: %w", file.Path, err)
+	}
+
+	backupPath := ""
+	if doesFileExist(file.Path) {
+		backupPath, err = backupFile(file.Path)
+		if err != nil {
+			_ = os.Remove(tmpPath)
+			return fmt.Errorf("error backing up file %s: %w", file.Path, err)
+		}
+	}
+
+	err = os.Rename(tmpPath, file.Path)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		if backupPath != "" {
+			_ = os.Remove(backupPath)
+		}
+		return fmt.Errorf("error writing to file %s: %w", file.Path, err)
+	}
+
+	tx.writtenFiles = append(tx.writtenFiles, writtenFile{path: file.Path, backupPath: backupPath})
+	return nil
+}
+
+// mkdirAll works like os.MkdirAll, but remembers which directories it had to create so they can be removed on rollback.
+func (tx *fileTransaction) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; !doesFileExist(d); d = filepath.Dir(d) {
+		missing = append(missing, d)
+		if d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// outermost first, so rollback can remove them innermost first
+	for i := len(missing) - 1; i >= 0; i-- {
+		tx.createdDirs = append(tx.createdDirs, missing[i])
+	}
+	return nil
+}
+
+func (tx *fileTransaction) rollback() error {
+	var errs []error
+
+	for i := len(tx.writtenFiles) - 1; i >= 0; i-- {
+		file := tx.writtenFiles[i]
+		if file.backupPath != "" {
+			err := os.Rename(file.backupPath, file.path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error restoring %s: %w", file.path, err))
+			}
+			continue
+		}
+
+		err := os.Remove(file.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("error removing %s: %w", file.path, err))
+		}
+	}
+
+	for i := len(tx.createdDirs) - 1; i >= 0; i-- {
+		// only succeeds if the directory is empty, which is what we want
+		_ = os.Remove(tx.createdDirs[i])
+	}
+
+	tx.writtenFiles = nil
+	tx.createdDirs = nil
+	return errors.Join(errs...)
+}
+
+func (tx *fileTransaction) commit() {
+	for _, file := range tx.writtenFiles {
+		if file.backupPath != "" {
+			_ = os.Remove(file.backupPath)
+		}
+	}
+
+	tx.writtenFiles = nil
+	tx.createdDirs = nil
+}
+
+func backupFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return writeTempFile(filepath.Dir(filePath), filepath.Base(filePath)+".bak-*", data)
+}
+
+func writeTempFile(dir string, pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp(dir, "."+pattern)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitFiles(t *testing.T) {
+	dir := t.TempDir()
+	existingPath := filepath.Join(dir, "config", "routes.rb")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(existingPath), os.ModePerm))
+	assert.NoError(t, os.WriteFile(existingPath, []byte("old routes"), 0644))
+
+	newPath := filepath.Join(dir, "actions", "books", "get_books.rb")
+
+	err := commitFiles([]renderedFile{
+		{Path: existingPath, Data: []byte("new routes")},
+		{Path: newPath, Data: []byte("new action")},
+	})
+	assert.NoError(t, err)
+
+	assertFileContents(t, existingPath, "new routes")
+	assertFileContents(t, newPath, "new action")
+
+	// no temp or backup files left lying around
+	entries, err := os.ReadDir(filepath.Dir(existingPath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCommitFiles_RollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	existingPath := filepath.Join(dir, "config", "routes.rb")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(existingPath), os.ModePerm))
+	assert.NoError(t, os.WriteFile(existingPath, []byte("old routes"), 0644))
+
+	newPath := filepath.Join(dir, "actions", "books", "get_books.rb")
+
+	// a directory sitting where a file needs to go makes the final rename fail
+	blockedPath := filepath.Join(dir, "actions", "contracts.rb")
+	assert.NoError(t, os.MkdirAll(filepath.Join(blockedPath, "not_empty"), os.ModePerm))
+
+	err := commitFiles([]renderedFile{
+		{Path: existingPath, Data: []byte("new routes")},
+		{Path: newPath, Data: []byte("new action")},
+		{Path: blockedPath, Data: []byte("new contracts")},
+	})
+	assert.Error(t, err)
+
+	assertFileContents(t, existingPath, "old routes")
+	assert.NoFileExists(t, newPath)
+	assert.NoDirExists(t, filepath.Join(dir, "actions", "books"))
+
+	entries, err := os.ReadDir(filepath.Dir(existingPath))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func assertFileContents(t *testing.T, filePath string, expected string) {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"os"
+	"path/filepath"
+)
+
+// bundleRun implements the bundle subcommand, which writes a spec and every local file it $refs out as a single
+// self-contained spec.
+func bundleRun(arguments []string) exitCode {
+	flags := flag.NewFlagSet("oapi-hanami-codegen bundle", flag.ContinueOnError)
+	inputFilePtr := flags.String("inputFile", "", "file path of OpenAPI spec")
+	outputPtr := flags.String("output", "", "file to write the bundled spec to, as JSON if it ends in .json and YAML otherwise (defaults to stdout, as YAML)")
+
+	err := flags.Parse(arguments)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing args: %s\n", err)
+		return exitError
+	}
+
+	if *inputFilePtr == "" {
+		fmt.Fprintf(os.Stderr, "error parsing args: must provide an inputFile\n")
+		return exitError
+	}
+
+	swagger, warnings, err := loadSwagger(*inputFilePtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load spec: %s\n", err)
+		return exitError
+	}
+	printWarnings(warnings)
+
+	data, err := marshalSpec(swagger, filepath.Ext(*outputPtr) == ".json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to bundle spec: %s\n", err)
+		return exitError
+	}
+
+	if *outputPtr == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = commitFiles([]renderedFile{{Path: *outputPtr, Data: data}})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write bundled spec: %s\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+func marshalSpec(swagger *openapi3.T, asJson bool) ([]byte, error) {
+	data, err := json.Marshal(swagger)
+	if err != nil {
+		return nil, err
+	}
+
+	if asJson {
+		var buf bytes.Buffer
+		err = json.Indent(&buf, data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+
+	return yaml.JSONToYAML(data)
+}
@@ -0,0 +1,113 @@
+package main
+
+import "strings"
+
+// ClientTemplateModel is a Ruby HTTP client for a slice's operations, for other apps to call it with. Its responses
+// are checked with the slice's response contracts, so the client and the API share contracts.rb and schemas.rb.
+type ClientTemplateModel struct {
+	AppName   string
+	SliceName string
+	// Classes are the client's classes, one for each module, which is to say each tag unless ModuleNaming says
+	// otherwise.
+	Classes []ClientClassTemplateModel
+	// Requires are the paths of the slice's schemas.rb and contracts.rb relative to the client, without .rb, which
+	// the Writer fills in since it decides where they all go.
+	Requires []string
+}
+
+// ClientClassTemplateModel is a client class for a module, with a method for each of the module's operations.
+type ClientClassTemplateModel struct {
+	SliceName  string
+	ModuleName string
+	// ClassName is the module flattened into one constant, e.g. AuthorsBooks, since there's no autoloader to define
+	// the modules in between.
+	ClassName  string
+	Operations []ClientOperationTemplateModel
+}
+
+type ClientOperationTemplateModel struct {
+	// MethodName is the snake cased action name, e.g. get_book_by_id.
+	MethodName string
+	// Method and Path are the operation's, as written in the spec, e.g. GET /books/{bookId}.
+	Method string
+	Path   string
+	// RequestPath is a Ruby string of the path to request, including the slice's mount, with the path params
+	// interpolated, e.g. "/api/books/#{escape(book_id)}".
+	RequestPath string
+	// Params are the method's keyword arguments: the path params, then query params, then the body.
+	Params []ClientParamTemplateModel
+	// ResponseContract is the name of the operation's response contract in contracts.rb.
+	ResponseContract string
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+type ClientParamTemplateModel struct {
+	// Name is the param's name as sent, and Argument is its keyword argument, e.g. bookId and book_id. The body's
+	// argument is body.
+	Name     string
+	Argument string
+	// In is path, query or body.
+	In       string
+	Required bool
+}
+
+// GenerateClientTemplateModels generates a client for each slice.
+func (g Generator) GenerateClientTemplateModels() ([]ClientTemplateModel, error) {
+	var clientTemplateModels []ClientTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		classes := map[string]*ClientClassTemplateModel{}
+		for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+			class, ok := classes[operationDefinition.ModuleName]
+			if !ok {
+				class = &ClientClassTemplateModel{
+					SliceName:  sliceName,
+					ModuleName: operationDefinition.ModuleName,
+					ClassName:  moduleConstantPrefix(operationDefinition.ModuleName),
+				}
+				classes[operationDefinition.ModuleName] = class
+			}
+			class.Operations = append(class.Operations, g.clientOperationTemplateModel(operationDefinition))
+		}
+
+		clientTemplateModel := ClientTemplateModel{AppName: g.AppName, SliceName: sliceName}
+		for _, moduleName := range sortedKeys(classes) {
+			clientTemplateModel.Classes = append(clientTemplateModel.Classes, *classes[moduleName])
+		}
+
+		clientTemplateModels = append(clientTemplateModels, clientTemplateModel)
+	}
+
+	return clientTemplateModels, nil
+}
+
+func (g Generator) clientOperationTemplateModel(operationDefinition OperationDefinition) ClientOperationTemplateModel {
+	// rubyLiteral leaves {bookId} alone, as it's not an interpolation, so it can be swapped for one after
+	requestPath := rubyLiteral(g.sliceMount(operationDefinition.SliceName) + strings.TrimSuffix(g.routePath(operationDefinition), "/"))
+
+	var params []ClientParamTemplateModel
+	for _, param := range operationDefinition.PathParams {
+		argument := toSnake(param.ParamName)
+		requestPath = strings.ReplaceAll(requestPath, "{"+param.ParamName+"}", "#{escape("+argument+")}")
+		params = append(params, ClientParamTemplateModel{Name: param.ParamName, Argument: argument, In: "path", Required: true})
+	}
+	for _, param := range operationDefinition.QueryParams {
+		if extensions, _ := readSchemaExtensions(param.Spec.Extensions); extensions.Skip {
+			continue
+		}
+		params = append(params, ClientParamTemplateModel{Name: param.ParamName, Argument: toSnake(param.ParamName), In: "query", Required: param.Required})
+	}
+	if operationDefinition.RequestBodySchema != nil {
+		params = append(params, ClientParamTemplateModel{Argument: "body", In: "body", Required: operationDefinition.Spec.RequestBody.Value.Required})
+	}
+
+	return ClientOperationTemplateModel{
+		MethodName:       toSnake(operationDefinition.ActionName),
+		Method:           operationDefinition.Method,
+		Path:             operationDefinition.Path,
+		RequestPath:      requestPath,
+		Params:           params,
+		ResponseContract: responseContractName(operationDefinition),
+		Extensions:       decodedExtensions(operationDefinition.Spec.Extensions),
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_GenerateClientTemplateModels(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_examples.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	clientTemplateModels, err := g.GenerateClientTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating client template models: %s\n", err)
+	}
+	assert.Len(t, clientTemplateModels, 1)
+	assert.Len(t, clientTemplateModels[0].Classes, 1)
+
+	reviews := clientTemplateModels[0].Classes[0]
+	assert.Equal(t, "Reviews", reviews.ClassName)
+	assert.Len(t, reviews.Operations, 2)
+
+	listReviews := reviews.Operations[0]
+	assert.Equal(t, "list_reviews", listReviews.MethodName)
+	assert.Equal(t, `"/api/books/#{escape(book_id)}/reviews"`, listReviews.RequestPath)
+	assert.Equal(t, "ListReviewsResponseContract", listReviews.ResponseContract)
+	// the skipped debug param is left out
+	assert.Equal(t, []ClientParamTemplateModel{
+		{Name: "bookId", Argument: "book_id", In: "path", Required: true},
+		{Name: "page", Argument: "page", In: "query", Required: true},
+		{Name: "sort", Argument: "sort", In: "query"},
+	}, listReviews.Params)
+
+	addReview := reviews.Operations[1]
+	assert.Equal(t, ClientParamTemplateModel{Argument: "body", In: "body"}, addReview.Params[1])
+}
+
+func TestConfig_Artifacts_ClientIsOptIn(t *testing.T) {
+	assert.False(t, (&Config{}).Artifacts().Includes(ArtifactClient))
+	assert.True(t, (&Config{Generate: []string{"client"}}).Artifacts().Includes(ArtifactClient))
+}
+
+func TestWriter_HanamiLayout_Client(t *testing.T) {
+	outputDir := generateInto(t, &Config{
+		Input:     "fixtures/test_spec_examples.yaml",
+		AppName:   "Bookshop",
+		SliceName: "Catalogue",
+		Generate:  []string{"client"},
+	}, LayoutHanami)
+
+	client, err := os.ReadFile(filepath.Join(outputDir, "client/catalogue.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(client), `require_relative "../slices/catalogue/actions/contracts"`)
+	assert.Contains(t, string(client), `require_relative "catalogue/reviews"`)
+
+	reviews, err := os.ReadFile(filepath.Join(outputDir, "client/catalogue/reviews.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(reviews), "def list_reviews(book_id:, page:, sort: nil)")
+	assert.Contains(t, string(reviews), "contract: Catalogue::Actions::Contracts::AddReviewResponseContract")
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/invopop/yaml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultConfigFileNames are looked for in the working directory when no -config flag is given.
+var defaultConfigFileNames = []string{
+	"oapi-hanami-codegen.yaml",
+	"oapi-hanami-codegen.yml",
+	"oapi-hanami-codegen.json",
+}
+
+// Config holds everything needed for a generation run. It's read from a YAML or JSON config file if there is
+// one, and any flags given on the command line take precedence over it.
+type Config struct {
+	// Input is the path to the OpenAPI spec.
+	Input string `json:"input"`
+	// Inputs lists more specs (or globs of them) to generate from alongside Input, combined according to SpecMode.
+	Inputs    []string `json:"inputs"`
+	SpecMode  SpecMode `json:"specMode"`
+	AppName   string   `json:"appName"`
+	SliceName string   `json:"sliceName"`
+	OutputDir string   `json:"outputDir"`
+	// TemplatesDir is a directory of templates overlaid on the built-in ones, see loadTemplates.
+	TemplatesDir string `json:"templatesDir"`
+	// Slices routes operations into slices other than SliceName, keyed by slice name. See sliceNameForOperation.
+	Slices map[string]SliceConfig `json:"slices"`
+	// Tags holds per-tag overrides, keyed by tag name.
+	Tags map[string]TagConfig `json:"tags"`
+	// TypeMappings maps an OpenAPI "type" or "type:format" to the dry-types type used in contracts and schemas,
+	// e.g. "string:date-time": ":date_time". These take precedence over defaultTypeMappings.
+	TypeMappings map[string]string `json:"typeMappings"`
+	// Generate lists the artifacts to generate, see AllArtifacts. Empty means the DefaultArtifacts.
+	Generate []string `json:"generate"`
+	// Exclude lists artifacts to leave out, e.g. for teams that maintain their own routes.rb.
+	Exclude []string `json:"exclude"`
+	// Layout decides where generated files go and what the base actions are called, see Layout.
+	Layout Layout `json:"layout"`
+	// OperationNaming decides what operations without an operationId are called, see OperationNaming.
+	OperationNaming OperationNaming `json:"operationNaming"`
+	// ModuleNaming decides which module an operation is generated into, see ModuleNaming.
+	ModuleNaming ModuleNaming `json:"moduleNaming"`
+	// TagPolicy decides which tag names the module of an operation with several, see TagPolicy.
+	TagPolicy TagPolicy `json:"tagPolicy"`
+	// TagPriority lists tags in the order they're picked as modules under TagPolicyPriority.
+	TagPriority []string `json:"tagPriority"`
+	// Persist lists component schemas that map to database tables, as ExtensionPersist on them would.
+	Persist []string `json:"persist"`
+	// Migrations drafts a migration creating the table of each persisted schema.
+	Migrations bool `json:"migrations"`
+	// RequestStructs has actions call their services with the operation's request struct rather than a hash.
+	RequestStructs bool `json:"requestStructs"`
+	// Decimals is how response serializers write BigDecimals, see DecimalFormat.
+	Decimals DecimalFormat `json:"decimals"`
+	// KeyTransform decides what services call properties and params, see KeyTransform.
+	KeyTransform KeyTransform `json:"keyTransform"`
+	// MiddlewareMode is the validation middleware's mode unless it's given one when it's mounted, see MiddlewareMode.
+	MiddlewareMode MiddlewareMode `json:"middlewareMode"`
+}
+
+// Layout is the shape of the generated output.
+type Layout string
+
+const (
+	// LayoutFlat puts everything straight into the output dir: actions/, services/, base_action.rb and
+	// config/routes.rb, for copying into an app by hand.
+	LayoutFlat Layout = "flat"
+	// LayoutHanami treats the output dir as the root of an existing Hanami 2 app, and follows its conventions:
+	// slices/<slice>/actions/, slices/<slice>/action.rb (<Slice>::Action) and config/routes.rb.
+	LayoutHanami Layout = "hanami"
+)
+
+type TagConfig struct {
+	// Module is the Ruby module name to use for operations with this tag, instead of the tag itself.
+	Module string `json:"module"`
+	// Skip leaves operations with this tag out of the generated code altogether.
+	Skip bool `json:"skip"`
+}
+
+type SliceConfig struct {
+	// Tags routes operations with any of these tags into the slice.
+	Tags []string `json:"tags"`
+	// PathPrefix routes operations under this path into the slice, e.g. /admin. The slice is mounted at the
+	// prefix, so the URLs stay the same as in the spec.
+	PathPrefix string `json:"pathPrefix"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		AppName:         "HanamiApp",
+		SliceName:       "API",
+		OutputDir:       "gen",
+		Layout:          LayoutFlat,
+		SpecMode:        SpecModeMerge,
+		OperationNaming: OperationNamingPath,
+		ModuleNaming:    ModuleNamingTag,
+		TagPolicy:       TagPolicyFirst,
+		Decimals:        DecimalFormatNumber,
+		KeyTransform:    KeyTransformSnake,
+		MiddlewareMode:  MiddlewareModeWarn,
+	}
+}
+
+// findConfigFile returns the first of defaultConfigFileNames that exists in the working directory, if any.
+func findConfigFile() string {
+	for _, fileName := range defaultConfigFileNames {
+		if doesFileExist(fileName) {
+			return fileName
+		}
+	}
+
+	return ""
+}
+
+// loadConfigFile reads the config file at filePath over the top of the defaults. Relative paths in the file
+// are resolved relative to the file itself, so the same config works from any working directory.
+func loadConfigFile(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	// YAML is a superset of JSON, so this handles both
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", filePath, err)
+	}
+
+	config := defaultConfig()
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(config)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", filePath, err)
+	}
+
+	configDir := filepath.Dir(filePath)
+	config.Input = resolvePath(configDir, config.Input)
+	for i := range config.Inputs {
+		config.Inputs[i] = resolvePath(configDir, config.Inputs[i])
+	}
+	config.OutputDir = resolvePath(configDir, config.OutputDir)
+	config.TemplatesDir = resolvePath(configDir, config.TemplatesDir)
+
+	return config, nil
+}
+
+func resolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+var rubyConstantRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+var rubyModuleRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*(::[A-Z][A-Za-z0-9_]*)*$`)
+
+var typeMappingKeyRegex = regexp.MustCompile(`^(string|integer|number|boolean)(:[A-Za-z0-9_-]+)?$`)
+
+// Validate checks the config for problems, and reports all of them at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Input == "" && len(c.Inputs) == 0 {
+		errs = append(errs, errors.New("input: must provide an OpenAPI spec to generate from"))
+	} else if _, err := c.InputFiles(); err != nil {
+		errs = append(errs, fmt.Errorf("input: %w", err))
+	}
+
+	if c.SpecMode != SpecModeMerge && c.SpecMode != SpecModeSlicePerSpec {
+		errs = append(errs, fmt.Errorf("specMode: unknown mode %q, must be one of %s, %s", c.SpecMode, SpecModeMerge, SpecModeSlicePerSpec))
+	}
+	if c.SpecMode == SpecModeSlicePerSpec && c.Layout != LayoutHanami {
+		errs = append(errs, fmt.Errorf("specMode: %s needs layout: %s", SpecModeSlicePerSpec, LayoutHanami))
+	}
+
+	if !rubyConstantRegex.MatchString(c.AppName) {
+		errs = append(errs, fmt.Errorf("appName: %q must be a valid Ruby constant, e.g. HanamiApp", c.AppName))
+	}
+
+	if !rubyConstantRegex.MatchString(c.SliceName) {
+		errs = append(errs, fmt.Errorf("sliceName: %q must be a valid Ruby constant, e.g. API", c.SliceName))
+	}
+
+	if c.OutputDir == "" {
+		errs = append(errs, errors.New("outputDir: must not be empty"))
+	}
+
+	if c.Layout != LayoutFlat && c.Layout != LayoutHanami {
+		errs = append(errs, fmt.Errorf("layout: unknown layout %q, must be one of %s, %s", c.Layout, LayoutFlat, LayoutHanami))
+	}
+
+	if c.OperationNaming != OperationNamingPath && c.OperationNaming != OperationNamingRest {
+		errs = append(errs, fmt.Errorf("operationNaming: unknown strategy %q, must be one of %s, %s", c.OperationNaming, OperationNamingPath, OperationNamingRest))
+	}
+
+	if c.ModuleNaming != ModuleNamingTag && c.ModuleNaming != ModuleNamingPath {
+		errs = append(errs, fmt.Errorf("moduleNaming: unknown strategy %q, must be one of %s, %s", c.ModuleNaming, ModuleNamingTag, ModuleNamingPath))
+	}
+
+	switch c.TagPolicy {
+	case TagPolicyFirst, TagPolicyError:
+	case TagPolicyPriority:
+		if len(c.TagPriority) == 0 {
+			errs = append(errs, fmt.Errorf("tagPriority: must list tags for tagPolicy: %s", TagPolicyPriority))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tagPolicy: unknown policy %q, must be one of %s, %s, %s", c.TagPolicy, TagPolicyFirst, TagPolicyError, TagPolicyPriority))
+	}
+
+	if c.Decimals != DecimalFormatNumber && c.Decimals != DecimalFormatString {
+		errs = append(errs, fmt.Errorf("decimals: unknown format %q, must be one of %s, %s", c.Decimals, DecimalFormatNumber, DecimalFormatString))
+	}
+
+	if c.KeyTransform != KeyTransformSnake && c.KeyTransform != KeyTransformCamel && c.KeyTransform != KeyTransformNone {
+		errs = append(errs, fmt.Errorf("keyTransform: unknown transform %q, must be one of %s, %s, %s", c.KeyTransform, KeyTransformSnake, KeyTransformCamel, KeyTransformNone))
+	}
+
+	if c.MiddlewareMode != MiddlewareModeWarn && c.MiddlewareMode != MiddlewareModeEnforce {
+		errs = append(errs, fmt.Errorf("middlewareMode: unknown mode %q, must be one of %s, %s", c.MiddlewareMode, MiddlewareModeWarn, MiddlewareModeEnforce))
+	}
+
+	slicesByTag := map[string]string{}
+	for _, sliceName := range sortedKeys(c.Slices) {
+		sliceConfig := c.Slices[sliceName]
+		if !rubyConstantRegex.MatchString(sliceName) {
+			errs = append(errs, fmt.Errorf("slices: %q must be a valid Ruby constant, e.g. Admin", sliceName))
+		}
+		if sliceConfig.PathPrefix != "" && !strings.HasPrefix(sliceConfig.PathPrefix, "/") {
+			errs = append(errs, fmt.Errorf("slices.%s.pathPrefix: %q must start with /", sliceName, sliceConfig.PathPrefix))
+		}
+		for _, tag := range sliceConfig.Tags {
+			if other, ok := slicesByTag[tag]; ok {
+				errs = append(errs, fmt.Errorf("slices.%s.tags: tag %q is already routed into slice %s", sliceName, tag, other))
+			}
+			slicesByTag[tag] = sliceName
+		}
+	}
+	if len(c.Slices) > 0 && c.Layout != LayoutHanami {
+		errs = append(errs, fmt.Errorf("slices: generating into multiple slices needs layout: %s", LayoutHanami))
+	}
+
+	for _, tag := range sortedKeys(c.Tags) {
+		module := c.Tags[tag].Module
+		if module != "" && !rubyConstantRegex.MatchString(module) {
+			errs = append(errs, fmt.Errorf("tags.%s.module: %q must be a valid Ruby constant, e.g. Books", tag, module))
+		}
+	}
+
+	for _, key := range sortedKeys(c.TypeMappings) {
+		if !typeMappingKeyRegex.MatchString(key) {
+			errs = append(errs, fmt.Errorf("typeMappings: %q must be a type (string, integer, number or boolean), optionally followed by :format", key))
+		}
+		if strings.TrimSpace(c.TypeMappings[key]) == "" {
+			errs = append(errs, fmt.Errorf("typeMappings.%s: must not be empty", key))
+		}
+	}
+
+	for _, name := range append(append([]string{}, c.Generate...), c.Exclude...) {
+		if !isKnownArtifact(name) {
+			errs = append(errs, fmt.Errorf("generate/exclude: unknown artifact %q, must be one of %s", name, joinArtifacts(AllArtifacts)))
+		}
+	}
+
+	if len(errs) == 0 && len(c.Artifacts()) == 0 {
+		errs = append(errs, errors.New("generate/exclude: nothing left to generate"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// InputFiles lists every spec file to generate from, with globs expanded.
+func (c *Config) InputFiles() ([]string, error) {
+	var inputs []string
+	if c.Input != "" {
+		inputs = append(inputs, c.Input)
+	}
+	inputs = append(inputs, c.Inputs...)
+
+	return expandInputs(inputs)
+}
+
+// skipsAnyOf reports whether any of an operation's tags are skipped, which leaves the operation out.
+func (c *Config) skipsAnyOf(tags []string) bool {
+	for _, tag := range tags {
+		if c.Tags[tag].Skip {
+			return true
+		}
+	}
+	return false
+}
+
+// Artifacts returns the set of artifacts selected by Generate and Exclude.
+func (c *Config) Artifacts() ArtifactSet {
+	set := NewArtifactSet(DefaultArtifacts...)
+	if len(c.Generate) > 0 {
+		set = ArtifactSet{}
+		for _, name := range c.Generate {
+			set[Artifact(name)] = true
+		}
+	}
+
+	for _, name := range c.Exclude {
+		delete(set, Artifact(name))
+	}
+
+	return set
+}
+
+func joinArtifacts(artifacts []Artifact) string {
+	names := make([]string, len(artifacts))
+	for i, artifact := range artifacts {
+		names[i] = string(artifact)
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitList splits a comma separated flag value, e.g. "contracts, schemas".
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
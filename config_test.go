@@ -0,0 +1,190 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArgs_ConfigFile(t *testing.T) {
+	config, err := parseArgs([]string{"-config", "fixtures/config/oapi-hanami-codegen.yaml"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+
+	expected := &Config{
+		Input:           filepath.Join("fixtures", "test_spec.yaml"),
+		AppName:         "Bookshop",
+		SliceName:       "Catalogue",
+		OutputDir:       "gen",
+		Layout:          LayoutFlat,
+		SpecMode:        SpecModeMerge,
+		OperationNaming: OperationNamingPath,
+		ModuleNaming:    ModuleNamingTag,
+		TagPolicy:       TagPolicyFirst,
+		Decimals:        DecimalFormatNumber,
+		KeyTransform:    KeyTransformSnake,
+		MiddlewareMode:  MiddlewareModeWarn,
+		Tags: map[string]TagConfig{
+			"books": {Module: "Library"},
+		},
+		TypeMappings: map[string]string{
+			"integer":          ":float",
+			"string:date-time": ":date_time",
+		},
+	}
+
+	assert.Equal(t, expected, config)
+}
+
+func TestParseArgs_FlagsOverrideConfigFile(t *testing.T) {
+	config, err := parseArgs([]string{
+		"-config", "fixtures/config/oapi-hanami-codegen.yaml",
+		"-appName", "OtherApp",
+		"-outputDir", "out",
+	})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+
+	assert.Equal(t, "OtherApp", config.AppName)
+	assert.Equal(t, "out", config.OutputDir)
+	// not given as a flag, so the config file wins over the flag's default
+	assert.Equal(t, "Catalogue", config.SliceName)
+}
+
+func TestParseArgs_NoConfigFile(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+
+	expected := defaultConfig()
+	expected.Input = "fixtures/test_spec.yaml"
+	assert.Equal(t, expected, config)
+}
+
+func TestParseArgs_InvalidConfigFile(t *testing.T) {
+	_, err := parseArgs([]string{"-config", "fixtures/config/invalid.yaml"})
+	assert.ErrorContains(t, err, "input: must provide an OpenAPI spec")
+	assert.ErrorContains(t, err, `appName: "bookshop" must be a valid Ruby constant`)
+	assert.ErrorContains(t, err, `tags.books.module: "library" must be a valid Ruby constant`)
+	assert.ErrorContains(t, err, `typeMappings: "date" must be a type`)
+}
+
+func TestLoadConfigFile_UnknownKey(t *testing.T) {
+	_, err := loadConfigFile("fixtures/config/unknown_key.yaml")
+	assert.ErrorContains(t, err, `unknown field "inputFile"`)
+}
+
+func TestNewGeneratorFromConfig(t *testing.T) {
+	config, err := parseArgs([]string{"-config", "fixtures/config/oapi-hanami-codegen.yaml"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateContractsFileTemplateModel("Catalogue")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	for _, operationDefinition := range g.OperationDefinitions {
+		assert.Equal(t, "Library", operationDefinition.ModuleName)
+	}
+
+	// GetBookByIdResponseContract.avatar.id is an integer, which is mapped to :float
+	avatar := model.Contracts[3].Attributes[1]
+	assert.Equal(t, "avatar", avatar.AttributeName)
+	assert.Equal(t, ":float", avatar.NestedAttributes[0].AttributeType)
+}
+
+func TestNewGeneratorFromConfig_SkipTag(t *testing.T) {
+	g, err := NewGeneratorFromConfig(&Config{
+		Input:     "fixtures/test_spec.yaml",
+		AppName:   "TestApp",
+		SliceName: "API",
+		Tags: map[string]TagConfig{
+			"books": {Skip: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	assert.Empty(t, g.OperationDefinitions)
+}
+
+func TestParseArgs_GenerateAndExclude(t *testing.T) {
+	config, err := parseArgs([]string{
+		"-inputFile", "fixtures/test_spec.yaml",
+		"-generate", "contracts, schemas,routes",
+		"-exclude", "routes",
+	})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+
+	assert.Equal(t, NewArtifactSet(ArtifactContracts, ArtifactSchemas), config.Artifacts())
+}
+
+func TestParseArgs_UnknownArtifact(t *testing.T) {
+	_, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-generate", "contracts,models"})
+	assert.ErrorContains(t, err, `unknown artifact "models"`)
+}
+
+func TestParseArgs_TagPriority(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-tagPolicy", "priority", "-tagPriority", "books, authors"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+	assert.Equal(t, []string{"books", "authors"}, config.TagPriority)
+
+	_, err = parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-tagPolicy", "priority"})
+	assert.ErrorContains(t, err, "tagPriority: must list tags for tagPolicy: priority")
+}
+
+func TestParseArgs_RequestStructs(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-requestStructs"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+	assert.True(t, config.RequestStructs)
+}
+
+func TestParseArgs_Decimals(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-decimals", "string"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+	assert.Equal(t, DecimalFormatString, config.Decimals)
+
+	_, err = parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-decimals", "float"})
+	assert.ErrorContains(t, err, `decimals: unknown format "float", must be one of number, string`)
+}
+
+func TestParseArgs_KeyTransform(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-keyTransform", "none"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+	assert.Equal(t, KeyTransformNone, config.KeyTransform)
+
+	_, err = parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-keyTransform", "kebab"})
+	assert.ErrorContains(t, err, `keyTransform: unknown transform "kebab", must be one of snake, camel, none`)
+}
+
+func TestParseArgs_MiddlewareMode(t *testing.T) {
+	config, err := parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-middlewareMode", "enforce"})
+	if err != nil {
+		t.Fatalf("error parsing args: %s\n", err)
+	}
+	assert.Equal(t, MiddlewareModeEnforce, config.MiddlewareMode)
+
+	_, err = parseArgs([]string{"-inputFile", "fixtures/test_spec.yaml", "-middlewareMode", "strict"})
+	assert.ErrorContains(t, err, `middlewareMode: unknown mode "strict", must be one of warn, enforce`)
+}
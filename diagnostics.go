@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic is a problem found at Pointer, a JSON pointer, in the spec File.
+type Diagnostic struct {
+	File    string `json:"file"`
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Pointer == "" {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s#%s: %s", d.File, d.Pointer, d.Message)
+}
+
+// DiagnosticsError is a set of problems that stop a spec from being loaded.
+type DiagnosticsError struct {
+	Summary     string
+	Diagnostics []Diagnostic
+}
+
+func (e *DiagnosticsError) Error() string {
+	lines := []string{e.Summary + ":"}
+	for _, diagnostic := range e.Diagnostics {
+		lines = append(lines, diagnostic.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonPointer joins tokens into a JSON pointer (RFC 6901), e.g. /paths/~1books/get for "paths", "/books", "get".
+func jsonPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
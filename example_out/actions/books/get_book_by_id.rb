@@ -1,7 +1,7 @@
 module API
   module Actions
     module Books
-      class GetBookById < API::Action
+      class GetBookById < PetstoreApp::BaseAction
         include Deps[service: "services.books.get_book_by_id"]
         params Contracts::GetBookByIdRequestContract
 
@@ -16,8 +16,7 @@ module API
           if response_body_validation_result.failure?
             raise BadResponseShapeError
           end
-
-          response.body = response_body_validation_result.values.to_h.to_json
+          response.body = JSON.generate(Serializers::Responses::GetBookById.call(response_body_validation_result.to_h))
         end
       end
     end
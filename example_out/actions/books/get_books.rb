@@ -1,7 +1,7 @@
 module API
   module Actions
     module Books
-      class GetBooks < API::Action
+      class GetBooks < PetstoreApp::BaseAction
         include Deps[service: "services.books.get_books"]
         params Contracts::GetBooksRequestContract
 
@@ -16,8 +16,7 @@ module API
           if response_body_validation_result.failure?
             raise BadResponseShapeError
           end
-
-          response.body = response_body_validation_result.values.to_h.to_json
+          response.body = JSON.generate(Serializers::Responses::GetBooks.call(response_body_validation_result.to_h))
         end
       end
     end
@@ -1,13 +1,53 @@
+# auto_register: false
 require "dry/validation"
 
 module API
   module Actions
     module Contracts
+      # KeyMap renames keys from their names in the spec to what services call them, e.g. "firstName" to
+      # :first_name, including keys nested in objects and arrays of them. Keys it doesn't know are left alone, so
+      # keys that have been renamed already stay as they are. revert renames the keys of errors back.
+      class KeyMap
+        def initialize(keys)
+          @keys = keys
+          @names = keys.to_h { |name, (key, nested)| [key, [name.to_sym, nested]] }
+        end
+
+        def call(value)
+          case value
+          when Hash
+            value.to_h do |name, nested_value|
+              key, nested = @keys.fetch(name.to_s) { [name, nil] }
+              [key, nested ? KeyMap.resolve(nested).call(nested_value) : nested_value]
+            end
+          when Array then value.map { |item| call(item) }
+          else value
+          end
+        end
+
+        def revert(errors)
+          return errors unless errors.is_a?(Hash)
+
+          errors.to_h do |key, nested_errors|
+            # errors of array items are keyed by their index
+            next [key, revert(nested_errors)] if key.is_a?(Integer)
+
+            name, nested = @names.fetch(key) { [key, nil] }
+            [name, nested ? KeyMap.resolve(nested).revert(nested_errors) : nested_errors]
+          end
+        end
+
+        # resolve looks up a KeyMap that's given as a lambda, as ones in a cycle are.
+        def self.resolve(key_map)
+          key_map.is_a?(Proc) ? key_map.call : key_map
+        end
+      end
+
       class GetBooksRequestContract < Hanami::Action::Params
         params do
         end
       end
-      
+
       class GetBooksResponseContract < Dry::Validation::Contract
         params do
   optional(:books).array(:hash) do
@@ -16,14 +56,21 @@ module API
   end
         end
       end
-      
+
       class GetBookByIdRequestContract < Hanami::Action::Params
         params do
         end
       end
-      
+
       class GetBookByIdResponseContract < Dry::Validation::Contract
+        KEYS = KeyMap.new({
+  "avatar" => [:avatar, KeyMap.new({
+  "profileImageUrl" => [:profile_image_url],
+})],
+})
+
         params do
+          before(:key_coercer) { |result| KEYS.call(result.to_h) }
   required(:author).value(:string)
   optional(:avatar).value(:hash) do
   optional(:id).value(:integer)
@@ -40,41 +87,51 @@ module API
   required(:title).value(:string)
         end
       end
-      
+
       class GetAllPetsRequestContract < Hanami::Action::Params
         params do
   required(:page).value(:integer)
   optional(:q).value(:string)
         end
       end
-      
+
       class GetAllPetsResponseContract < Dry::Validation::Contract
         params do
   optional(:age).value(:integer)
   optional(:name).value(:string)
         end
       end
-      
+
       class CreatePetRequestContract < Hanami::Action::Params
         params do
   optional(:age).value(:integer)
   optional(:name).value(:string)
         end
       end
-      
+
       class CreatePetResponseContract < Dry::Validation::Contract
         params do
   optional(:age).value(:integer)
   optional(:name).value(:string)
         end
       end
-      
+
       class GetPetByIdRequestContract < Hanami::Action::Params
+        KEYS = KeyMap.new({
+  "petId" => [:pet_id],
+})
+
         params do
+          before(:key_coercer) { |result| KEYS.call(result.to_h) }
   required(:pet_id).value(:integer)
         end
+
+        # errors are reported under the names in the spec, which is what the request has
+        def errors
+          KEYS.revert(super.to_h)
+        end
       end
-      
+
       class GetPetByIdResponseContract < Dry::Validation::Contract
         params do
   optional(:id).value(:integer)
@@ -83,7 +140,6 @@ module API
   optional(:owners).array(Schemas::Owner)
         end
       end
-      
     end
   end
 end
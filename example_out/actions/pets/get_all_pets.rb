@@ -1,7 +1,7 @@
 module API
   module Actions
     module Pets
-      class GetAllPets < API::Action
+      class GetAllPets < PetstoreApp::BaseAction
         include Deps[service: "services.pets.get_all_pets"]
         params Contracts::GetAllPetsRequestContract
 
@@ -16,8 +16,7 @@ module API
           if response_body_validation_result.failure?
             raise BadResponseShapeError
           end
-
-          response.body = response_body_validation_result.values.to_h.to_json
+          response.body = JSON.generate(Serializers::Responses::GetAllPets.call(response_body_validation_result.to_h))
         end
       end
     end
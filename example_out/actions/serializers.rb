@@ -0,0 +1,120 @@
+# auto_register: false
+require "bigdecimal"
+require "time"
+
+module API
+  module Actions
+    # Serializers write response bodies with only the properties the spec documents, under the names it gives them,
+    # so nothing a service returns by accident ends up in a response.
+    module Serializers
+      # ObjectSerializer writes a hash, or anything with to_h, e.g. a struct, as a JSON object of its properties,
+      # each a [name, key, type] triple. Keys that aren't there are left out.
+      class ObjectSerializer
+        def initialize(properties)
+          @properties = properties
+        end
+
+        def call(value)
+          value = value.to_h
+          @properties.each_with_object({}) do |(name, key, type), json|
+            json[name] = Serializers.serialize(type, value[key]) if value.key?(key)
+          end
+        end
+      end
+
+      # serialize writes a value as its type says: [type] for an array of them, a serializer, a lambda returning
+      # one, or the name of one of the methods below.
+      def self.serialize(type, value)
+        return nil if value.nil?
+
+        case type
+        when Array then value.map { |item| serialize(type.first, item) }
+        when Proc then serialize(type.call, value)
+        when Symbol then public_send(type, value)
+        else type.call(value)
+        end
+      end
+
+      def self.string(value)
+        value.to_s
+      end
+
+      def self.integer(value)
+        Integer(value)
+      end
+
+      def self.number(value)
+        value.is_a?(BigDecimal) ? value.to_f : value
+      end
+
+      def self.boolean(value)
+        value
+      end
+
+      def self.iso8601(value)
+        value.respond_to?(:iso8601) ? value.iso8601 : value.to_s
+      end
+
+      def self.value(value)
+        value
+      end
+      
+      Owner = ObjectSerializer.new([
+        ["age", :age, :integer],
+        ["id", :id, :string],
+        ["name", :name, :string],
+      ])
+      
+      Pet = ObjectSerializer.new([
+        ["id", :id, :integer],
+        ["name", :name, :string],
+        ["nicknames", :nicknames, [:string]],
+        ["owners", :owners, [Owner]],
+      ])
+      
+      # Responses are the serializers actions write each operation's response body with.
+      module Responses
+        GetBooks = ObjectSerializer.new([
+          ["books", :books, [ObjectSerializer.new([
+  ["author", :author, :string],
+  ["title", :title, :string],
+  ])]],
+        ])
+
+        GetBookById = ObjectSerializer.new([
+          ["author", :author, :string],
+          ["avatar", :avatar, ObjectSerializer.new([
+  ["id", :id, :integer],
+  ["profileImageUrl", :profile_image_url, :string],
+  ])],
+          ["reviews", :reviews, [ObjectSerializer.new([
+  ["rating", :rating, :integer],
+  ["text", :text, :string],
+  ["user", :user, ObjectSerializer.new([
+  ["id", :id, :string],
+  ["name", :name, :string],
+  ])],
+  ])]],
+          ["title", :title, :string],
+        ])
+
+        GetAllPets = ObjectSerializer.new([
+          ["age", :age, :integer],
+          ["name", :name, :string],
+        ])
+
+        CreatePet = ObjectSerializer.new([
+          ["age", :age, :integer],
+          ["name", :name, :string],
+        ])
+
+        GetPetById = ObjectSerializer.new([
+          ["id", :id, :integer],
+          ["name", :name, :string],
+          ["nicknames", :nicknames, [:string]],
+          ["owners", :owners, [Owner]],
+        ])
+      end
+    end
+  end
+end
@@ -0,0 +1,99 @@
+# auto_register: false
+require "dry/struct"
+
+module API
+  module Actions
+    module Structs
+      module Types
+        include Dry.Types()
+      end
+
+      # Base is what the structs, and the ones nested in them, inherit from. It takes string keys as well as
+      # symbols, for hashes from JSON.
+      class Base < Dry::Struct
+        abstract
+        transform_keys(&:to_sym)
+      end
+      
+      class Owner < Base
+  attribute? :age, Types::Integer
+  attribute? :id, Types::String
+  attribute? :name, Types::String
+      end
+      
+      class Pet < Base
+  attribute? :id, Types::Integer
+  attribute :name, Types::String
+  attribute? :nicknames, Types::Array.of(Types::String)
+  attribute? :owners, Types::Array.of(Owner)
+      end
+      
+      # Requests are what actions hand their services, made from the params once they're validated.
+      module Requests
+        class GetBooks < Base
+        end
+
+        class GetBookById < Base
+        end
+
+        class GetAllPets < Base
+  attribute :page, Types::Integer
+  attribute? :q, Types::String
+        end
+
+        class CreatePet < Base
+  attribute? :age, Types::Integer
+  attribute? :name, Types::String
+        end
+
+        class GetPetById < Base
+  attribute :pet_id, Types::Integer
+        end
+      end
+
+      # Responses are for services to return, which actions serialize as the response body.
+      module Responses
+        class GetBooks < Base
+  attribute? :books, Types::Array do
+  attribute? :author, Types::String
+  attribute? :title, Types::String
+  end
+        end
+
+        class GetBookById < Base
+  attribute :author, Types::String
+  attribute? :avatar do
+  attribute? :id, Types::Integer
+  attribute? :profile_image_url, Types::String
+  end
+  attribute :reviews, Types::Array do
+  attribute? :rating, Types::Integer
+  attribute :text, Types::String
+  attribute :user do
+  attribute? :id, Types::String
+  attribute? :name, Types::String
+  end
+  end
+  attribute :title, Types::String
+        end
+
+        class GetAllPets < Base
+  attribute? :age, Types::Integer
+  attribute? :name, Types::String
+        end
+
+        class CreatePet < Base
+  attribute? :age, Types::Integer
+  attribute? :name, Types::String
+        end
+
+        class GetPetById < Base
+  attribute? :id, Types::Integer
+  attribute :name, Types::String
+  attribute? :nicknames, Types::Array.of(Types::String)
+  attribute? :owners, Types::Array.of(Owner)
+        end
+      end
+    end
+  end
+end
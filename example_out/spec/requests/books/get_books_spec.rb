@@ -0,0 +1,25 @@
+# frozen_string_literal: true
+
+require "json"
+require "rack/test"
+
+RSpec.describe "GET /books", type: :request do
+  include Rack::Test::Methods
+
+  let(:app) { Hanami.app }
+  let(:path) { "/api/books" }
+  let(:query) { {} }
+
+  def make_request(query)
+    url = query.empty? ? path : "#{path}?#{Rack::Utils.build_nested_query(query)}"
+    get url
+  end
+
+  it "responds with a body that matches GetBooksResponseContract" do
+    make_request(query)
+
+    expect(last_response).to be_successful
+    result = API::Actions::Contracts::GetBooksResponseContract.new.call(JSON.parse(last_response.body))
+    expect(result.errors.to_h).to be_empty
+  end
+end
@@ -0,0 +1,26 @@
+# frozen_string_literal: true
+
+require "json"
+require "rack/test"
+
+RSpec.describe "POST /pets", type: :request do
+  include Rack::Test::Methods
+
+  let(:app) { Hanami.app }
+  let(:path) { "/api/pets" }
+  let(:query) { {} }
+  let(:body) { { "age" => 1, "name" => "string" } }
+
+  def make_request(query, body)
+    url = query.empty? ? path : "#{path}?#{Rack::Utils.build_nested_query(query)}"
+    post url, JSON.generate(body), "CONTENT_TYPE" => "application/json"
+  end
+
+  it "responds with a body that matches CreatePetResponseContract" do
+    make_request(query, body)
+
+    expect(last_response).to be_successful
+    result = API::Actions::Contracts::CreatePetResponseContract.new.call(JSON.parse(last_response.body))
+    expect(result.errors.to_h).to be_empty
+  end
+end
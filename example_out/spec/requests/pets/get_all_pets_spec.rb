@@ -0,0 +1,31 @@
+# frozen_string_literal: true
+
+require "json"
+require "rack/test"
+
+RSpec.describe "GET /pets", type: :request do
+  include Rack::Test::Methods
+
+  let(:app) { Hanami.app }
+  let(:path) { "/api/pets" }
+  let(:query) { { "page" => 1, "q" => "string" } }
+
+  def make_request(query)
+    url = query.empty? ? path : "#{path}?#{Rack::Utils.build_nested_query(query)}"
+    get url
+  end
+
+  it "responds with a body that matches GetAllPetsResponseContract" do
+    make_request(query)
+
+    expect(last_response).to be_successful
+    result = API::Actions::Contracts::GetAllPetsResponseContract.new.call(JSON.parse(last_response.body))
+    expect(result.errors.to_h).to be_empty
+  end
+
+  it "responds with 422 without page" do
+    make_request(query.except("page"))
+
+    expect(last_response.status).to eq(422)
+  end
+end
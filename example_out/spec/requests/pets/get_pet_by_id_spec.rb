@@ -0,0 +1,25 @@
+# frozen_string_literal: true
+
+require "json"
+require "rack/test"
+
+RSpec.describe "GET /pets/{petId}", type: :request do
+  include Rack::Test::Methods
+
+  let(:app) { Hanami.app }
+  let(:path) { "/api/pets/1" }
+  let(:query) { {} }
+
+  def make_request(query)
+    url = query.empty? ? path : "#{path}?#{Rack::Utils.build_nested_query(query)}"
+    get url
+  end
+
+  it "responds with a body that matches GetPetByIdResponseContract" do
+    make_request(query)
+
+    expect(last_response).to be_successful
+    result = API::Actions::Contracts::GetPetByIdResponseContract.new.call(JSON.parse(last_response.body))
+    expect(result.errors.to_h).to be_empty
+  end
+end
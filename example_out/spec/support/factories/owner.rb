@@ -0,0 +1,16 @@
+# frozen_string_literal: true
+
+module Factories
+  # Example Owner payloads, keyed like Schemas::Owner. Keyword arguments override any of them.
+  module Owner
+    module_function
+
+    def build(**overrides)
+      {
+        age: 1,
+        id: "string",
+        name: "string"
+      }.merge(overrides)
+    end
+  end
+end
@@ -0,0 +1,17 @@
+# frozen_string_literal: true
+
+module Factories
+  # Example Pet payloads, keyed like Schemas::Pet. Keyword arguments override any of them.
+  module Pet
+    module_function
+
+    def build(**overrides)
+      {
+        id: 10,
+        name: "doggie",
+        nicknames: ["string"],
+        owners: [Factories::Owner.build]
+      }.merge(overrides)
+    end
+  end
+end
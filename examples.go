@@ -0,0 +1,147 @@
+package main
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"math"
+)
+
+// Example values, for generated specs to send. The spec's own example or examples win, then a schema's default or
+// first enum value, and failing all that a value is made up from the schema's type, so it at least passes the
+// generated contracts.
+
+// parameterExample is an example value for a query or path param.
+func parameterExample(parameter *openapi3.Parameter) any {
+	if parameter.Example != nil {
+		return parameter.Example
+	}
+	if example, ok := firstExample(parameter.Examples); ok {
+		return example
+	}
+
+	return schemaExample(parameter.Schema)
+}
+
+// mediaTypeExample is an example request or response body.
+func mediaTypeExample(mediaType *openapi3.MediaType) any {
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+	if example, ok := firstExample(mediaType.Examples); ok {
+		return example
+	}
+
+	return schemaExample(mediaType.Schema)
+}
+
+// firstExample picks from named examples by name, so the same one gets picked every time.
+func firstExample(examples openapi3.Examples) (any, bool) {
+	for _, name := range sortedKeys(examples) {
+		example := examples[name]
+		if example != nil && example.Value != nil && example.Value.Value != nil {
+			return example.Value.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+func schemaExample(schemaRef *openapi3.SchemaRef) any {
+	return schemaExampleWithin(schemaRef, map[*openapi3.Schema]bool{})
+}
+
+// schemaExampleWithin makes up an example for a schema, with seen holding the schemas it's already in the middle
+// of, so a schema that refers back to itself ends in nil instead of going round forever.
+func schemaExampleWithin(schemaRef *openapi3.SchemaRef, seen map[*openapi3.Schema]bool) any {
+	if schemaRef == nil || schemaRef.Value == nil {
+		return nil
+	}
+
+	schema := schemaRef.Value
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	if seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+	defer delete(seen, schema)
+
+	switch {
+	case len(schema.AllOf) > 0:
+		example := map[string]any{}
+		for _, part := range schema.AllOf {
+			if properties, ok := schemaExampleWithin(part, seen).(map[string]any); ok {
+				example = merge(example, properties)
+			}
+		}
+		return example
+	case len(schema.OneOf) > 0:
+		return schemaExampleWithin(schema.OneOf[0], seen)
+	case len(schema.AnyOf) > 0:
+		return schemaExampleWithin(schema.AnyOf[0], seen)
+	}
+
+	switch schema.Type {
+	case "string":
+		return stringExample(schema.Format)
+	case "integer":
+		return math.Ceil(numberExample(schema, 1))
+	case "number":
+		return numberExample(schema, 1.5)
+	case "boolean":
+		return true
+	case "array":
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{schemaExampleWithin(schema.Items, seen)}
+	case "object", "":
+		example := map[string]any{}
+		for name, property := range schema.Properties {
+			// skipped properties aren't in the contracts, so there's no point sending them
+			if extensions, _ := readSchemaExtensions(property.Value.Extensions); extensions.Skip {
+				continue
+			}
+			example[name] = schemaExampleWithin(property, seen)
+		}
+		return example
+	}
+
+	return nil
+}
+
+// stringExample makes up a string in the given format, so it passes format checks like :uuid_v4?.
+func stringExample(format string) string {
+	switch format {
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uri", "url":
+		return "https://example.com"
+	default:
+		return "string"
+	}
+}
+
+// numberExample is fallback, unless that's outside the schema's minimum or maximum.
+func numberExample(schema *openapi3.Schema, fallback float64) float64 {
+	if schema.Min != nil && fallback < *schema.Min {
+		return *schema.Min
+	}
+	if schema.Max != nil && fallback > *schema.Max {
+		return *schema.Max
+	}
+
+	return fallback
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_schemaExample(t *testing.T) {
+	recursive := openapi3.NewObjectSchema()
+	recursive.WithProperty("name", openapi3.NewStringSchema())
+	recursive.WithPropertyRef("parent", openapi3.NewSchemaRef("#/components/schemas/Category", recursive))
+
+	tests := []struct {
+		name   string
+		schema *openapi3.Schema
+		want   any
+	}{
+		{
+			name:   "its default comes before its enum",
+			schema: openapi3.NewStringSchema().WithDefault("draft").WithEnum("published", "draft"),
+			want:   "draft",
+		},
+		{
+			name:   "then its first enum value",
+			schema: openapi3.NewStringSchema().WithEnum("published", "draft"),
+			want:   "published",
+		},
+		{
+			name:   "strings are made up to suit their format",
+			schema: openapi3.NewDateTimeSchema(),
+			want:   "2024-01-01T00:00:00Z",
+		},
+		{
+			name:   "numbers stay within their minimum",
+			schema: openapi3.NewIntegerSchema().WithMin(10),
+			want:   float64(10),
+		},
+		{
+			name:   "arrays get one item",
+			schema: openapi3.NewArraySchema().WithItems(openapi3.NewBoolSchema()),
+			want:   []any{true},
+		},
+		{
+			name:   "schemas that refer to themselves stop",
+			schema: recursive,
+			want:   map[string]any{"name": "string", "parent": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schemaExample(openapi3.NewSchemaRef("", tt.schema)))
+		})
+	}
+}
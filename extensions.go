@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/deepmap/oapi-codegen/pkg/codegen"
+	"github.com/getkin/kin-openapi/openapi3"
+	"regexp"
+	"strings"
+)
+
+// ExtensionSlice on an operation picks the slice it's generated into, e.g. `x-hanami-slice: Admin`.
+// At the root of a spec, it names the spec's slice in SpecModeSlicePerSpec.
+const ExtensionSlice = "x-hanami-slice"
+
+// ExtensionModule on an operation picks the module it's generated into whatever its tags or path, e.g.
+// `x-hanami-module: Library`. Nested modules are written out in full, e.g. Authors::Books.
+const ExtensionModule = "x-hanami-module"
+
+// ExtensionAction on an operation names its action and service classes, e.g. `x-hanami-action: Show`, whatever
+// its operationId. Contracts are still named after the operationId.
+const ExtensionAction = "x-hanami-action"
+
+// ExtensionService on an operation is the container key of an existing service for its action to call, e.g.
+// `x-hanami-service: services.catalogue.search`, instead of generating one.
+const ExtensionService = "x-hanami-service"
+
+// ExtensionBefore on an operation lists methods its action calls before handling a request, e.g.
+// `x-hanami-before: [authenticate!]`. A single method can be given as a string.
+const ExtensionBefore = "x-hanami-before"
+
+// ExtensionSkip leaves an operation, schema, property or parameter out of the generated code, e.g.
+// `x-hanami-skip: true`. Properties that refer to a skipped schema are left out along with it.
+const ExtensionSkip = "x-hanami-skip"
+
+// ExtensionRubyType on a schema, property or parameter is the dry-types type to use for it instead of mapping
+// its type, e.g. `x-hanami-ruby-type: Types::Money`. On a component schema, it's used wherever it's referred to.
+const ExtensionRubyType = "x-hanami-ruby-type"
+
+// ExtensionPersist on a component schema maps it to a database table, generating a ROM relation, repository and
+// entity struct for it, e.g. `x-hanami-persist: true`. See Config.Persist.
+const ExtensionPersist = "x-hanami-persist"
+
+// OperationExtensions are the x-hanami-* extensions read from an operation.
+type OperationExtensions struct {
+	Slice   string
+	Module  string
+	Action  string
+	Service string
+	Before  []string
+	Skip    bool
+}
+
+// SchemaExtensions are the x-hanami-* extensions read from a schema, or a parameter.
+type SchemaExtensions struct {
+	RubyType string
+	Skip     bool
+	Persist  bool
+}
+
+// ExtensionError is a problem with the value of the vendor extension Name.
+type ExtensionError struct {
+	Name string
+	Err  error
+}
+
+func (e *ExtensionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtensionError) Unwrap() error {
+	return e.Err
+}
+
+// extensionDiagnostics turns the ExtensionErrors joined together in err into diagnostics, each pointing at its
+// extension within the object at pointer.
+func extensionDiagnostics(inputFile string, pointer string, err error) []Diagnostic {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var diagnostics []Diagnostic
+	for _, err := range errs {
+		extensionPointer := pointer
+		var extensionErr *ExtensionError
+		if errors.As(err, &extensionErr) {
+			extensionPointer += jsonPointer(extensionErr.Name)
+		}
+		diagnostics = append(diagnostics, Diagnostic{inputFile, extensionPointer, err.Error()})
+	}
+	return diagnostics
+}
+
+var rubyMethodRegex = regexp.MustCompile(`^[a-z_][A-Za-z0-9_]*[!?]?$`)
+
+var containerKeyRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)*$`)
+
+// readOperationExtensions reads and checks the x-hanami-* extensions on an operation, reporting every problem
+// at once.
+func readOperationExtensions(extensions map[string]interface{}) (OperationExtensions, error) {
+	var operationExtensions OperationExtensions
+	var errs []error
+
+	read := func(name string, value *string, regex *regexp.Regexp, example string) {
+		var err error
+		*value, err = stringExtension(extensions, name)
+		if err != nil {
+			errs = append(errs, &ExtensionError{name, err})
+		} else if *value != "" && !regex.MatchString(*value) {
+			errs = append(errs, &ExtensionError{name, fmt.Errorf("%s %q must be %s", name, *value, example)})
+		}
+	}
+	read(ExtensionSlice, &operationExtensions.Slice, rubyConstantRegex, "a valid Ruby constant, e.g. Admin")
+	read(ExtensionModule, &operationExtensions.Module, rubyModuleRegex, "a valid Ruby module name, e.g. Books or Authors::Books")
+	read(ExtensionAction, &operationExtensions.Action, rubyConstantRegex, "a valid Ruby constant, e.g. Show")
+	read(ExtensionService, &operationExtensions.Service, containerKeyRegex, "a container key, e.g. services.books.show")
+
+	before, err := stringListExtension(extensions, ExtensionBefore)
+	if err != nil {
+		errs = append(errs, &ExtensionError{ExtensionBefore, err})
+	}
+	for _, method := range before {
+		if !rubyMethodRegex.MatchString(method) {
+			errs = append(errs, &ExtensionError{ExtensionBefore, fmt.Errorf("%s %q must be a Ruby method name, e.g. authenticate!", ExtensionBefore, method)})
+		}
+	}
+	operationExtensions.Before = before
+
+	err = boolExtension(extensions, ExtensionSkip, &operationExtensions.Skip)
+	if err != nil {
+		errs = append(errs, &ExtensionError{ExtensionSkip, err})
+	}
+
+	return operationExtensions, errors.Join(errs...)
+}
+
+// readSchemaExtensions reads and checks the x-hanami-* extensions on a schema or parameter, reporting every
+// problem at once.
+func readSchemaExtensions(extensions map[string]interface{}) (SchemaExtensions, error) {
+	var schemaExtensions SchemaExtensions
+	var errs []error
+
+	rubyType, err := stringExtension(extensions, ExtensionRubyType)
+	if err != nil {
+		errs = append(errs, &ExtensionError{ExtensionRubyType, err})
+	} else if _, set := extensions[ExtensionRubyType]; set && strings.TrimSpace(rubyType) == "" {
+		errs = append(errs, &ExtensionError{ExtensionRubyType, fmt.Errorf("%s must not be empty", ExtensionRubyType)})
+	}
+	schemaExtensions.RubyType = rubyType
+
+	err = boolExtension(extensions, ExtensionSkip, &schemaExtensions.Skip)
+	if err != nil {
+		errs = append(errs, &ExtensionError{ExtensionSkip, err})
+	}
+
+	err = boolExtension(extensions, ExtensionPersist, &schemaExtensions.Persist)
+	if err != nil {
+		errs = append(errs, &ExtensionError{ExtensionPersist, err})
+	}
+
+	return schemaExtensions, errors.Join(errs...)
+}
+
+// schemaExtensionDiagnostics checks the x-hanami-* extensions on every schema and parameter in swagger, which
+// are only read once generation is under way. Refs are checked where they're defined.
+func schemaExtensionDiagnostics(inputFile string, swagger *openapi3.T) []Diagnostic {
+	var diagnostics []Diagnostic
+	check := func(pointer string, extensions map[string]interface{}) {
+		if _, err := readSchemaExtensions(extensions); err != nil {
+			diagnostics = append(diagnostics, extensionDiagnostics(inputFile, pointer, err)...)
+		}
+	}
+
+	var visit func(pointer string, schemaRef *openapi3.SchemaRef)
+	visit = func(pointer string, schemaRef *openapi3.SchemaRef) {
+		if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+			return
+		}
+		check(pointer, schemaRef.Value.Extensions)
+		for _, name := range sortedKeys(schemaRef.Value.Properties) {
+			visit(pointer+jsonPointer("properties", name), schemaRef.Value.Properties[name])
+		}
+		visit(pointer+jsonPointer("items"), schemaRef.Value.Items)
+	}
+
+	for _, name := range sortedKeys(swagger.Components.Schemas) {
+		visit(jsonPointer("components", "schemas", name), swagger.Components.Schemas[name])
+	}
+
+	for _, requestPath := range codegen.SortedPathsKeys(swagger.Paths) {
+		operations := swagger.Paths[requestPath].Operations()
+		for _, method := range codegen.SortedOperationsKeys(operations) {
+			operation := operations[method]
+			pointer := jsonPointer("paths", requestPath, strings.ToLower(method))
+
+			for i, parameter := range operation.Parameters {
+				if parameter.Ref == "" && parameter.Value != nil {
+					parameterPointer := pointer + jsonPointer("parameters", fmt.Sprint(i))
+					check(parameterPointer, parameter.Value.Extensions)
+					visit(parameterPointer+jsonPointer("schema"), parameter.Value.Schema)
+				}
+			}
+
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				if mediaType := operation.RequestBody.Value.Content.Get(MediaTypeJson); mediaType != nil {
+					visit(pointer+jsonPointer("requestBody", "content", MediaTypeJson, "schema"), mediaType.Schema)
+				}
+			}
+
+			for _, status := range sortedKeys(operation.Responses) {
+				response := operation.Responses[status]
+				if response.Value == nil {
+					continue
+				}
+				if mediaType := response.Value.Content.Get(MediaTypeJson); mediaType != nil {
+					visit(pointer+jsonPointer("responses", status, "content", MediaTypeJson, "schema"), mediaType.Schema)
+				}
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// decodedExtensions decodes every vendor extension in extensions into plain values, so templates can use any of
+// them, e.g. {{index .Extensions "x-owner"}}. It's nil if there aren't any.
+func decodedExtensions(extensions map[string]interface{}) map[string]any {
+	var decoded map[string]any
+	for _, name := range sortedKeys(extensions) {
+		var value any
+		_, err := decodeExtension(extensions, name, &value)
+		if err != nil {
+			continue
+		}
+		if decoded == nil {
+			decoded = map[string]any{}
+		}
+		decoded[name] = value
+	}
+	return decoded
+}
+
+// stringExtension reads a vendor extension that should hold a string, returning "" if it isn't set.
+func stringExtension(extensions map[string]interface{}, name string) (string, error) {
+	var value string
+	_, err := decodeExtension(extensions, name, &value)
+	if err != nil {
+		return "", fmt.Errorf("%s must be a string: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// boolExtension reads a vendor extension that should hold a boolean into value, leaving it alone if it isn't set.
+func boolExtension(extensions map[string]interface{}, name string, value *bool) error {
+	_, err := decodeExtension(extensions, name, value)
+	if err != nil {
+		return fmt.Errorf("%s must be true or false", name)
+	}
+	return nil
+}
+
+// stringListExtension reads a vendor extension that should hold a list of strings, or a single string.
+func stringListExtension(extensions map[string]interface{}, name string) ([]string, error) {
+	var values []string
+	_, err := decodeExtension(extensions, name, &values)
+	if err == nil {
+		return values, nil
+	}
+
+	value, err := stringExtension(extensions, name)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a string or a list of strings", name)
+	}
+	return []string{value}, nil
+}
+
+// decodeExtension decodes the vendor extension called name into target, and reports whether it was set at all.
+// kin-openapi leaves extensions as raw JSON, so they're decoded the same way the rest of the spec is.
+func decodeExtension(extensions map[string]interface{}, name string, target any) (bool, error) {
+	raw, ok := extensions[name]
+	if !ok {
+		return false, nil
+	}
+
+	data, isRawMessage := raw.(json.RawMessage)
+	if !isRawMessage {
+		var err error
+		data, err = json.Marshal(raw)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, json.Unmarshal(data, target)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewGenerator_Extensions(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_extensions.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	// x-hanami-skip on DELETE /books/{bookId}
+	assert.Len(t, g.OperationDefinitions, 2)
+
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+	search, show := actionTemplateModels[0], actionTemplateModels[1]
+
+	assert.Equal(t, "SearchBooks", search.ActionName)
+	assert.Equal(t, "services.catalogue.search", search.ServiceKey)
+	assert.Equal(t, []string{"authenticate!"}, search.Before)
+
+	assert.Equal(t, "Show", show.ActionName)
+	assert.Equal(t, "GetBookRequestContract", show.RequestContract)
+	assert.Equal(t, "services.books.show", show.ServiceKey)
+	assert.Equal(t, []string{"authenticate!", "load_book"}, show.Before)
+	assert.Equal(t, "catalogue-team", show.Extensions["x-owner"])
+
+	// SearchBooks calls an existing service, so only Show gets one generated
+	serviceTemplateModels, err := g.GenerateServiceTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating service template models: %s\n", err)
+	}
+	assert.Len(t, serviceTemplateModels, 1)
+	assert.Equal(t, "Show", serviceTemplateModels[0].ServiceName)
+
+	contractsFileTemplateModel, err := g.GenerateContractsFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	// the debug param is skipped, and bookId has a Ruby type
+	getBookRequest := contractsFileTemplateModel.Contracts[2]
+	assert.Equal(t, "GetBookRequestContract", getBookRequest.ContractName)
+	assert.Len(t, getBookRequest.Attributes, 1)
+	assert.Equal(t, "Types::UUID", getBookRequest.Attributes[0].AttributeType)
+	assert.Equal(t, "Types::UUID", getBookRequest.Attributes[0].Extensions[ExtensionRubyType])
+
+	// internalNotes and the skipped Legacy schema are left out, and Money's Ruby type is used where it's referred to
+	getBookResponse := contractsFileTemplateModel.Contracts[3]
+	assert.Equal(t, "catalogue-team", getBookResponse.Extensions["x-owner"])
+	assert.Equal(t, []AttributeDefinition{
+		{AttributeName: "price", Key: "price", AttributeType: "Types::Money", Verb: "value", Extensions: map[string]any{ExtensionRubyType: "Types::Money"}},
+		{AttributeName: "title", Key: "title", AttributeType: ":string", Verb: "value"},
+	}, getBookResponse.Attributes)
+
+	schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating schemas file: %s\n", err)
+	}
+	var schemaNames []string
+	for _, schema := range schemasFileTemplateModel.Schemas {
+		schemaNames = append(schemaNames, schema.SchemaName)
+	}
+	assert.Equal(t, []string{"Money", "Book"}, schemaNames)
+	assert.Equal(t, "Types::Money", schemasFileTemplateModel.Schemas[0].Extensions[ExtensionRubyType])
+}
+
+func TestNewGenerator_InvalidExtensions(t *testing.T) {
+	_, err := NewGenerator("fixtures/test_spec_invalid_extensions.yaml", "TestApp", "API")
+	assert.ErrorContains(t, err, "#/components/schemas/Books/properties/total/x-hanami-skip: x-hanami-skip must be true or false")
+}
+
+func TestLintSpecs_InvalidExtensions(t *testing.T) {
+	problems := lintSpecs([]string{"fixtures/test_spec_invalid_extensions.yaml"}, defaultConfig())
+
+	var pointers []string
+	for _, problem := range problems {
+		assert.Equal(t, LintRuleInvalidExtension.ID, problem.Rule)
+		pointers = append(pointers, problem.Pointer)
+	}
+	assert.Equal(t, []string{
+		"/components/schemas/Books/properties/total/x-hanami-skip",
+		"/paths/~1books/get/x-hanami-action",
+		"/paths/~1books/get/x-hanami-before",
+	}, pointers)
+}
+
+func Test_readOperationExtensions(t *testing.T) {
+	extensions, err := readOperationExtensions(map[string]interface{}{
+		ExtensionModule: "Authors::Books",
+		ExtensionBefore: "authenticate!",
+		ExtensionSkip:   false,
+	})
+	if err != nil {
+		t.Fatalf("error reading extensions: %s\n", err)
+	}
+	assert.Equal(t, OperationExtensions{Module: "Authors::Books", Before: []string{"authenticate!"}}, extensions)
+
+	_, err = readOperationExtensions(map[string]interface{}{ExtensionService: "Services::Search"})
+	assert.ErrorContains(t, err, `x-hanami-service "Services::Search" must be a container key, e.g. services.books.show`)
+}
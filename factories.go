@@ -0,0 +1,159 @@
+package main
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"regexp"
+	"strings"
+)
+
+// FactoryTemplateModel is a factory of example payloads for a component schema, shaped the way a service gets them
+// once the contracts have run: keyed by symbols named as services call them, see factoryLiteral.
+type FactoryTemplateModel struct {
+	AppName    string
+	SchemaName string
+	// Attributes are the properties of an object schema, each with a Ruby expression for its example value.
+	Attributes []FactoryAttributeTemplateModel
+	// Value is a Ruby literal of an example, for schemas that aren't objects, and empty for ones that are.
+	Value string
+	// Extensions are the schema's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+type FactoryAttributeTemplateModel struct {
+	// Key is the property's key in the hash, a Ruby symbol without its colon, e.g. profile_image_url.
+	Key string
+	// Value is a Ruby expression for the property's example: a literal, or a call to another schema's factory.
+	Value string
+}
+
+// GenerateFactoryTemplateModels generates a factory for every component schema that's in schemas.rb. Properties
+// that refer to another component schema call its factory, so a change to an example shows up everywhere it's used.
+func (g Generator) GenerateFactoryTemplateModels() ([]FactoryTemplateModel, error) {
+	// factories are shared by every slice, and so are the schemas
+	schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel(g.SliceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var factoryTemplateModels []FactoryTemplateModel
+	for _, schemaTemplateModel := range schemasFileTemplateModel.Schemas {
+		schema := g.Schemas[schemaTemplateModel.SchemaName].Value
+		factoryTemplateModel := FactoryTemplateModel{
+			AppName:    g.AppName,
+			SchemaName: schemaTemplateModel.SchemaName,
+			Extensions: schemaTemplateModel.Extensions,
+		}
+
+		if schema.Type != "object" && len(schema.Properties) == 0 {
+			factoryTemplateModel.Value = factoryLiteral(schemaExample(g.Schemas[schemaTemplateModel.SchemaName]), g.serviceKey)
+			factoryTemplateModels = append(factoryTemplateModels, factoryTemplateModel)
+			continue
+		}
+
+		// an example of the whole schema wins over its properties' own
+		example, _ := schema.Example.(map[string]any)
+		for _, attributeDefinition := range schemaTemplateModel.Attributes {
+			property := schema.Properties[attributeDefinition.AttributeName]
+
+			var value string
+			if propertyExample, ok := example[attributeDefinition.AttributeName]; ok {
+				value = factoryLiteral(propertyExample, g.serviceKey)
+			} else if referenced := factoryReference(attributeDefinition); referenced != "" {
+				// a factory can't call one that calls it back, so the cycle's broken by leaving the property out
+				if g.schemaRefersTo(referenced, schemaTemplateModel.SchemaName) {
+					if !attributeDefinition.Required {
+						continue
+					}
+					value = "nil"
+				} else {
+					value = "Factories::" + referenced + ".build"
+					if attributeDefinition.Verb == "array" {
+						value = "[" + value + "]"
+					}
+				}
+			} else {
+				value = factoryLiteral(schemaExample(property), g.serviceKey)
+			}
+
+			factoryTemplateModel.Attributes = append(factoryTemplateModel.Attributes, FactoryAttributeTemplateModel{
+				Key:   rubySymbolKey(attributeDefinition.Key),
+				Value: value,
+			})
+		}
+
+		factoryTemplateModels = append(factoryTemplateModels, factoryTemplateModel)
+	}
+
+	return factoryTemplateModels, nil
+}
+
+// factoryReference is the component schema an attribute, or the items of an array attribute, is defined as in
+// schemas.rb, or empty if it's anything else, e.g. an x-hanami-ruby-type.
+func factoryReference(attributeDefinition AttributeDefinition) string {
+	schemaName, ok := strings.CutPrefix(attributeDefinition.AttributeType, "Schemas::")
+	if !ok {
+		return ""
+	}
+
+	return schemaName
+}
+
+// schemaRefersTo reports whether the component schema from refers to target, directly or through other schemas.
+func (g Generator) schemaRefersTo(from string, target string) bool {
+	visited := map[string]bool{}
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		if name == target {
+			return true
+		}
+		if visited[name] || g.Schemas[name] == nil {
+			return false
+		}
+		visited[name] = true
+
+		for _, referenced := range referencedSchemaNames(g.Schemas[name].Value, map[*openapi3.Schema]bool{}) {
+			if visit(referenced) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return visit(from)
+}
+
+var rubyBareSymbolRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// rubySymbolKey writes a hash key as a symbol, quoting it if it needs to be, e.g. "2fa":.
+func rubySymbolKey(key string) string {
+	if rubyBareSymbolRegex.MatchString(key) {
+		return key
+	}
+
+	return rubyLiteral(key)
+}
+
+// factoryLiteral writes an example as rubyLiteral does, but with hashes keyed by symbols named as serviceKey names
+// them, like the contracts' keys, e.g. { profile_image_url: "https://example.com" }.
+func factoryLiteral(value any, serviceKey func(string) string) string {
+	switch value := value.(type) {
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = factoryLiteral(item, serviceKey)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		if len(value) == 0 {
+			return "{}"
+		}
+		pairs := make([]string, 0, len(value))
+		for _, key := range sortedKeys(value) {
+			pairs = append(pairs, rubySymbolKey(serviceKey(key))+": "+factoryLiteral(value[key], serviceKey))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return rubyLiteral(value)
+	}
+}
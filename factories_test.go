@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerator_GenerateFactoryTemplateModels(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_factories.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	factoryTemplateModels, err := g.GenerateFactoryTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating factory template models: %s\n", err)
+	}
+
+	factories := map[string]FactoryTemplateModel{}
+	for _, factoryTemplateModel := range factoryTemplateModels {
+		factories[factoryTemplateModel.SchemaName] = factoryTemplateModel
+	}
+	assert.Len(t, factories, 3)
+
+	assert.Equal(t, `"admin"`, factories["Role"].Value)
+	assert.Empty(t, factories["Role"].Attributes)
+
+	// users and teams refer to each other, so only one side of that can call the other's factory
+	assert.Equal(t, []FactoryAttributeTemplateModel{
+		{Key: "created_at", Value: `"2024-01-01T00:00:00Z"`},
+		{Key: "email", Value: `"user@example.com"`},
+		{Key: "id", Value: `"3fa85f64-5717-4562-b3fc-2c963f66afa6"`},
+		{Key: "locale", Value: `"en-GB"`},
+		{Key: "role", Value: "Factories::Role.build"},
+	}, factories["User"].Attributes)
+	assert.Equal(t, []FactoryAttributeTemplateModel{
+		{Key: "name", Value: `"Platform"`},
+	}, factories["Team"].Attributes)
+}
+
+func Test_factoryLiteral(t *testing.T) {
+	assert.Equal(t, `{ "2_fa": true, profile_image_url: "https://example.com", tags: [{ tag_name: "a" }] }`, factoryLiteral(map[string]any{
+		"profileImageUrl": "https://example.com",
+		"2fa":             true,
+		"tags":            []any{map[string]any{"tagName": "a"}},
+	}, toSnake))
+}
@@ -1,29 +1,138 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/deepmap/oapi-codegen/pkg/codegen"
-	"github.com/deepmap/oapi-codegen/pkg/util"
 	"github.com/getkin/kin-openapi/openapi3"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type Generator struct {
-	AppName              string
-	SliceName            string // only support a single slice for now
+	AppName string
+	// SliceName is the default slice, for operations that aren't routed into one of Slices.
+	SliceName            string
+	Slices               map[string]SliceConfig
 	OperationDefinitions []OperationDefinition
-	Swagger              *openapi3.T
+	// Schemas are the component schemas of every input spec, merged together.
+	Schemas      openapi3.Schemas
+	TypeMappings map[string]string
+	Artifacts    ArtifactSet
+	Layout       Layout
+	// PersistedSchemas are the component schemas that map to database tables, see persistedSchemaNames.
+	PersistedSchemas []string
+	// Migrations drafts migrations creating the tables of PersistedSchemas.
+	Migrations bool
+	// RequestStructs has actions hand their services a request struct, see requestStructName.
+	RequestStructs bool
+	// Decimals is how serializers write BigDecimals.
+	Decimals DecimalFormat
+	// KeyTransform decides what services call properties and params.
+	KeyTransform KeyTransform
+	// MiddlewareMode is what the validation middleware does with requests and responses that don't match the spec.
+	MiddlewareMode MiddlewareMode
+	// Warnings are problems found in the input specs that don't stop generation, e.g. from converting Swagger 2.0.
+	Warnings []Diagnostic
 }
 
 func NewGenerator(inputFilePath string, appName string, sliceName string) (*Generator, error) {
-	swagger, err := loadSwagger(inputFilePath)
+	return NewGeneratorFromConfig(&Config{
+		Input:     inputFilePath,
+		AppName:   appName,
+		SliceName: sliceName,
+		Layout:    LayoutFlat,
+	})
+}
+
+func NewGeneratorFromConfig(config *Config) (*Generator, error) {
+	inputFiles, err := config.InputFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var operationDefinitions []OperationDefinition
+	schemas := openapi3.Schemas{}
+	schemaSources := map[string]string{}
+	var collisions []error
+	var warnings []Diagnostic
+
+	for _, inputFile := range inputFiles {
+		swagger, specWarnings, err := loadSwagger(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", inputFile, err)
+		}
+		warnings = append(warnings, specWarnings...)
+
+		if diagnostics := schemaExtensionDiagnostics(inputFile, swagger); len(diagnostics) > 0 {
+			return nil, &DiagnosticsError{Summary: "invalid x-hanami extensions", Diagnostics: diagnostics}
+		}
+
+		defaultSliceName := config.SliceName
+		if config.SpecMode == SpecModeSlicePerSpec {
+			defaultSliceName, err = specSliceName(inputFile, swagger)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		specOperationDefinitions, err := operationDefinitionsFromSpec(swagger, inputFile, defaultSliceName, config)
+		if err != nil {
+			if len(inputFiles) > 1 {
+				return nil, fmt.Errorf("error in %s: %w", inputFile, err)
+			}
+			return nil, err
+		}
+		operationDefinitions = append(operationDefinitions, specOperationDefinitions...)
+
+		if swagger.Components.Schemas != nil {
+			err = mergeSchemas(schemas, schemaSources, inputFile, swagger.Components.Schemas)
+			if err != nil {
+				collisions = append(collisions, err)
+			}
+		}
+	}
+
+	collisions = append(collisions, findOperationCollisions(operationDefinitions))
+	if err := errors.Join(collisions...); err != nil {
+		return nil, fmt.Errorf("input specs collide:\n%w", err)
+	}
+
+	persistedSchemas, err := persistedSchemaNames(schemas, config.Persist)
 	if err != nil {
 		return nil, err
 	}
 
-	codegenOperationDefinitions, err := codegen.OperationDefinitions(swagger)
+	g := &Generator{
+		AppName:              config.AppName,
+		SliceName:            config.SliceName,
+		Slices:               config.Slices,
+		OperationDefinitions: operationDefinitions,
+		Schemas:              schemas,
+		TypeMappings:         config.TypeMappings,
+		Artifacts:            config.Artifacts(),
+		Layout:               config.Layout,
+		PersistedSchemas:     persistedSchemas,
+		Migrations:           config.Migrations,
+		RequestStructs:       config.RequestStructs,
+		Decimals:             config.Decimals,
+		MiddlewareMode:       config.MiddlewareMode,
+		KeyTransform:         config.KeyTransform,
+		Warnings:             warnings,
+	}
+
+	if sliceNames := g.SliceNames(); g.Layout != LayoutHanami && len(sliceNames) > 1 {
+		return nil, fmt.Errorf("operations are routed into %d slices (%s), which needs the hanami layout", len(sliceNames), strings.Join(sliceNames, ", "))
+	}
+
+	return g, nil
+}
+
+func operationDefinitionsFromSpec(swagger *openapi3.T, inputFile string, defaultSliceName string, config *Config) ([]OperationDefinition, error) {
+	codegenOperationDefinitions, err := codegenOperationDefinitionsFromSpec(swagger)
 	if err != nil {
 		return nil, err
 	}
@@ -34,38 +143,150 @@ func NewGenerator(inputFilePath string, appName string, sliceName string) (*Gene
 		if err != nil {
 			return nil, err
 		}
+		operationDefinition.SpecPath = inputFile
+
+		operationDefinition.HanamiExtensions, err = readOperationExtensions(operationDefinition.Spec.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the extensions of %s: %w", operationDefinition.OperationId, err)
+		}
+		if operationDefinition.HanamiExtensions.Skip || config.skipsAnyOf(operationDefinition.Spec.Tags) {
+			continue
+		}
+
+		err = nameOperation(operationDefinition, defaultSliceName, config)
+		if err != nil {
+			return nil, fmt.Errorf("error naming %s: %w", operationDefinition.OperationId, err)
+		}
+
 		operationDefinitions = append(operationDefinitions, *operationDefinition)
 	}
 
-	return &Generator{
-		AppName:              appName,
-		SliceName:            sliceName,
-		OperationDefinitions: operationDefinitions,
-		Swagger:              swagger,
-	}, nil
+	return operationDefinitions, nil
 }
 
-func loadSwagger(filePath string) (*openapi3.T, error) {
-	swagger, err := util.LoadSwagger(filePath)
+// nameOperation picks the slice, module and action an operation is generated as, which lint goes by too. Its
+// HanamiExtensions need reading first.
+func nameOperation(operationDefinition *OperationDefinition, defaultSliceName string, config *Config) error {
+	operationDefinition.SliceName = sliceNameForOperation(*operationDefinition, defaultSliceName, config.Slices)
+
+	moduleName, err := moduleNameForOperation(*operationDefinition, config)
 	if err != nil {
-		return nil, fmt.Errorf("error loading swagger spec: %w", err)
+		return err
 	}
+	operationDefinition.ModuleName = moduleName
 
-	// todo fix this, don't want to have to call this to get things to work
-	_, err = codegen.Generate(swagger, codegen.Configuration{
-		PackageName: "main",
-		Generate:    codegen.GenerateOptions{},
-	})
+	if operationDefinition.Spec.OperationID == "" && config.OperationNaming == OperationNamingRest {
+		if actionName := restActionName(operationDefinition.Method, operationDefinition.Path, operationDefinition.ModuleName); actionName != "" {
+			// the module keeps the operationId, which names the contracts, unique across the slice
+			operationDefinition.ActionName = actionName
+			operationDefinition.OperationId = moduleConstantPrefix(operationDefinition.ModuleName) + actionName
+		}
+	}
+	if actionName := operationDefinition.HanamiExtensions.Action; actionName != "" {
+		operationDefinition.ActionName = actionName
+	}
+
+	return nil
+}
+
+// loadSwagger takes the spec at filePath as far as the generator needs it: loaded (and converted to OpenAPI 3
+// if it's Swagger 2.0), validated, and with any external refs resolved into its own components. Working out the
+// operations is left to codegenOperationDefinitionsFromSpec.
+func loadSwagger(filePath string) (*openapi3.T, []Diagnostic, error) {
+	swagger, warnings, err := loadSpec(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading swagger spec: %w", err)
+	}
+
+	err = swagger.Validate(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("error executing codegen.Generate: %w", err)
+		return nil, nil, fmt.Errorf("error validating swagger spec %s: %w", filePath, err)
+	}
+
+	internalizeRefs(swagger)
+
+	return swagger, warnings, nil
+}
+
+// codegenOperationDefinitionsFromSpec lists the operations in the spec, sorted by path and then method, as
+// codegen.OperationDefinitions would. Only the fields the generator uses are filled in, so no Go types are
+// generated along the way, and operations without an operationId are named after their method and path,
+// e.g. GetBooksBookId.
+func codegenOperationDefinitionsFromSpec(swagger *openapi3.T) ([]codegen.OperationDefinition, error) {
+	var operationDefinitions []codegen.OperationDefinition
+
+	for _, requestPath := range codegen.SortedPathsKeys(swagger.Paths) {
+		pathItem := swagger.Paths[requestPath]
+		operations := pathItem.Operations()
+		for _, method := range codegen.SortedOperationsKeys(operations) {
+			operation := operations[method]
+
+			operationId := operation.OperationID
+			if operationId == "" {
+				operationId = defaultOperationId(method, requestPath)
+			}
+			operationId = codegen.ToCamelCase(operationId)
+
+			operationDefinitions = append(operationDefinitions, codegen.OperationDefinition{
+				OperationId:  operationId,
+				Method:       method,
+				Path:         requestPath,
+				Summary:      operation.Summary,
+				Spec:         operation,
+				PathParams:   parameterDefinitions(pathItem, operation, openapi3.ParameterInPath),
+				HeaderParams: parameterDefinitions(pathItem, operation, openapi3.ParameterInHeader),
+				QueryParams:  parameterDefinitions(pathItem, operation, openapi3.ParameterInQuery),
+			})
+		}
+	}
+
+	return operationDefinitions, nil
+}
+
+// parameterDefinitions lists an operation's params in the given place, e.g. query, including the ones shared by its
+// path unless the operation overrides them. Only their specs are filled in, not the Go types oapi-codegen would
+// generate for them.
+func parameterDefinitions(pathItem *openapi3.PathItem, operation *openapi3.Operation, in string) []codegen.ParameterDefinition {
+	var parameterDefinitions []codegen.ParameterDefinition
+	for _, parameterRef := range pathItem.Parameters {
+		parameter := parameterRef.Value
+		if parameter.In == in && operation.Parameters.GetByInAndName(in, parameter.Name) == nil {
+			parameterDefinitions = append(parameterDefinitions, codegen.ParameterDefinition{ParamName: parameter.Name, In: in, Required: parameter.Required, Spec: parameter})
+		}
+	}
+	for _, parameterRef := range operation.Parameters {
+		parameter := parameterRef.Value
+		if parameter.In == in {
+			parameterDefinitions = append(parameterDefinitions, codegen.ParameterDefinition{ParamName: parameter.Name, In: in, Required: parameter.Required, Spec: parameter})
+		}
 	}
 
-	return swagger, nil
+	return parameterDefinitions
+}
+
+func defaultOperationId(method string, requestPath string) string {
+	operationId := strings.ToLower(method)
+	for _, part := range strings.Split(requestPath, "/") {
+		if part != "" {
+			operationId += "-" + part
+		}
+	}
+	return operationId
 }
 
 type OperationDefinition struct {
 	*codegen.OperationDefinition
-	ModuleName            string
+	// ActionName names the action and service classes within ModuleName, and is the operationId unless
+	// OperationNamingRest named the operation.
+	ActionName string
+	// ModuleName is the Ruby module the action and service go in, nested with :: under ModuleNamingPath,
+	// e.g. Authors::Books.
+	ModuleName string
+	SliceName  string
+	// SpecPath is the spec file the operation was defined in.
+	SpecPath string
+	// HanamiExtensions are the operation's x-hanami-* extensions.
+	HanamiExtensions      OperationExtensions
 	RequestBodySchema     *openapi3.SchemaRef
 	ResponseBody200Schema *openapi3.SchemaRef
 }
@@ -75,11 +296,6 @@ func NewOperationDefinition(codegenOperationDefinition codegen.OperationDefiniti
 		return nil, ErrSpecCannotBeNil
 	}
 
-	moduleName, err := safelyDigModuleName(codegenOperationDefinition)
-	if err != nil {
-		return nil, fmt.Errorf("error digging out module name from tags: %w", err)
-	}
-
 	requestBodySchema, err := safelyDigRequestBodySchema(codegenOperationDefinition)
 	if err != nil {
 		return nil, fmt.Errorf("error digging out request body schema: %w", err)
@@ -92,28 +308,143 @@ func NewOperationDefinition(codegenOperationDefinition codegen.OperationDefiniti
 
 	return &OperationDefinition{
 		OperationDefinition:   &codegenOperationDefinition,
-		ModuleName:            moduleName,
+		ActionName:            codegenOperationDefinition.OperationId,
 		RequestBodySchema:     requestBodySchema,
 		ResponseBody200Schema: responseBody200Schema,
 	}, nil
 }
 
+// moduleNameForOperation picks the module an operation is generated into. An x-hanami-module extension on the
+// operation wins, otherwise it's up to config.ModuleNaming, and config.TagPolicy for operations with several tags.
+// Path modules are named after the path within the operation's slice, so a slice mounted at /admin doesn't put
+// everything in an Admin module, unless that leaves nothing to name one after, as for the slice's root.
+func moduleNameForOperation(operationDefinition OperationDefinition, config *Config) (string, error) {
+	if moduleName := operationDefinition.HanamiExtensions.Module; moduleName != "" {
+		return moduleName, nil
+	}
+
+	if config.ModuleNaming == ModuleNamingPath {
+		moduleName := ""
+		pathPrefix := strings.TrimSuffix(config.Slices[operationDefinition.SliceName].PathPrefix, "/")
+		if pathPrefix != "" && hasPathPrefix(operationDefinition.Path, pathPrefix) {
+			moduleName = pathModuleName(strings.TrimPrefix(operationDefinition.Path, pathPrefix))
+		}
+		if moduleName == "" {
+			moduleName = pathModuleName(operationDefinition.Path)
+		}
+		if moduleName == "" {
+			return "", ErrNoPathModule
+		}
+		return moduleName, nil
+	}
+
+	moduleName, err := safelyDigModuleName(operationDefinition.Spec.Tags, config)
+	if err != nil {
+		return "", fmt.Errorf("error digging out module name from tags: %w", err)
+	}
+	if module := config.Tags[moduleName].Module; module != "" {
+		moduleName = module
+	}
+	return moduleName, nil
+}
+
+// sliceNameForOperation picks the slice an operation is generated into. An x-hanami-slice extension on the
+// operation wins, then a slice claiming one of its tags, then the slice with the longest matching path prefix.
+// Anything left over goes into the default slice.
+func sliceNameForOperation(operationDefinition OperationDefinition, defaultSliceName string, slices map[string]SliceConfig) string {
+	sliceName := operationDefinition.HanamiExtensions.Slice
+	if sliceName != "" {
+		return sliceName
+	}
+
+	for _, tag := range operationDefinition.Spec.Tags {
+		for _, name := range sortedKeys(slices) {
+			if isInArray(slices[name].Tags, tag) {
+				return name
+			}
+		}
+	}
+
+	longestPrefix := 0
+	for _, name := range sortedKeys(slices) {
+		prefix := slices[name].PathPrefix
+		if prefix != "" && hasPathPrefix(operationDefinition.Path, prefix) && len(prefix) > longestPrefix {
+			sliceName = name
+			longestPrefix = len(prefix)
+		}
+	}
+	if sliceName != "" {
+		return sliceName
+	}
+
+	return defaultSliceName
+}
+
+func hasPathPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// SliceNames lists the slices that have operations in them, default slice first and the rest alphabetically.
+// With no operations at all there's still the default slice.
+func (g Generator) SliceNames() []string {
+	seen := map[string]bool{}
+	for _, operationDefinition := range g.OperationDefinitions {
+		seen[operationDefinition.SliceName] = true
+	}
+
+	var sliceNames []string
+	if seen[g.SliceName] || len(seen) == 0 {
+		sliceNames = append(sliceNames, g.SliceName)
+	}
+	for _, sliceName := range sortedKeys(seen) {
+		if sliceName != g.SliceName {
+			sliceNames = append(sliceNames, sliceName)
+		}
+	}
+
+	return sliceNames
+}
+
+func (g Generator) operationDefinitionsInSlice(sliceName string) []OperationDefinition {
+	var operationDefinitions []OperationDefinition
+	for _, operationDefinition := range g.OperationDefinitions {
+		if operationDefinition.SliceName == sliceName {
+			operationDefinitions = append(operationDefinitions, operationDefinition)
+		}
+	}
+	return operationDefinitions
+}
+
 var MediaTypeJson = "application/json"
 
 var ErrMissingTags = errors.New("operation definition must specify at least one tag")
+var ErrAmbiguousTags = errors.New("operation has several tags, so which one is its module is ambiguous, list it in tagPriority or set x-hanami-module")
+var ErrNoPathModule = errors.New("operation path must have a segment that isn't a param to name its module after")
 var ErrSpecCannotBeNil = errors.New("operation definition Spec attribute cannot be nil")
 var ErrMalformedSpec = errors.New("operation definition Spec attribute is malformed")
 var ErrMalformedSpecNoRequestBodyJsonMediaType = errors.New("operation definition Spec RequestBody must define an application/json media type")
 var Err200ResponseBodyMissing = errors.New("operation definition must define a 200 response body")
 var Err200ResponseBodyNoJsonMediaType = errors.New("operation definition 200 response body is missing application/json response")
 
-func safelyDigModuleName(codegenOperationDefinition codegen.OperationDefinition) (string, error) {
-	tags := codegenOperationDefinition.Spec.Tags
-
+// safelyDigModuleName picks the tag that names the module of an operation with tags, according to
+// config.TagPolicy. An operation with just the one tag is never ambiguous.
+func safelyDigModuleName(tags []string, config *Config) (string, error) {
 	if len(tags) == 0 {
 		return "", ErrMissingTags
 	}
-	return tags[0], nil
+	if len(tags) == 1 || config.TagPolicy == TagPolicyFirst {
+		return tags[0], nil
+	}
+
+	if config.TagPolicy == TagPolicyPriority {
+		for _, tag := range config.TagPriority {
+			if isInArray(tags, tag) {
+				return tag, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrAmbiguousTags, strings.Join(tags, ", "))
 }
 
 func safelyDigRequestBodySchema(codegenOperationDefinition codegen.OperationDefinition) (*openapi3.SchemaRef, error) {
@@ -159,52 +490,207 @@ func safelyDigResponseBody200Schema(codegenOperationDefinition codegen.Operation
 	return response.Value.Content.Get(MediaTypeJson).Schema, nil
 }
 
+// Artifact is one kind of file the generator can produce.
+type Artifact string
+
+const (
+	ArtifactRoutes       Artifact = "routes"
+	ArtifactBaseAction   Artifact = "base_action"
+	ArtifactActions      Artifact = "actions"
+	ArtifactServices     Artifact = "services"
+	ArtifactContracts    Artifact = "contracts"
+	ArtifactSchemas      Artifact = "schemas"
+	ArtifactStructs      Artifact = "structs"
+	ArtifactSerializers  Artifact = "serializers"
+	ArtifactRequestSpecs Artifact = "request_specs"
+	ArtifactFactories    Artifact = "factories"
+	ArtifactClient       Artifact = "client"
+	ArtifactPersistence  Artifact = "persistence"
+	ArtifactMiddleware   Artifact = "middleware"
+	// ArtifactMock generates services that return the spec's example responses, in place of ArtifactServices' empty
+	// ones, see mockResponse.
+	ArtifactMock Artifact = "mock"
+)
+
+// DefaultArtifacts are the artifacts generated when none are picked.
+var DefaultArtifacts = []Artifact{
+	ArtifactRoutes,
+	ArtifactBaseAction,
+	ArtifactActions,
+	ArtifactServices,
+	ArtifactContracts,
+	ArtifactSchemas,
+	ArtifactStructs,
+	ArtifactSerializers,
+	ArtifactRequestSpecs,
+	ArtifactFactories,
+	ArtifactPersistence,
+}
+
+// AllArtifacts are all the artifacts there are. The rest are only generated when they're picked: ArtifactClient,
+// which is for other apps, ArtifactMiddleware, which checks requests the generated contracts already check, and
+// ArtifactMock, whose services would take the place of the ones to fill in.
+var AllArtifacts = append(append([]Artifact{}, DefaultArtifacts...), ArtifactClient, ArtifactMiddleware, ArtifactMock)
+
+// ArtifactSet is the set of artifacts selected for generation.
+type ArtifactSet map[Artifact]bool
+
+func NewArtifactSet(artifacts ...Artifact) ArtifactSet {
+	set := ArtifactSet{}
+	for _, artifact := range artifacts {
+		set[artifact] = true
+	}
+	return set
+}
+
+func (s ArtifactSet) Includes(artifact Artifact) bool {
+	return s[artifact]
+}
+
+func isKnownArtifact(name string) bool {
+	for _, artifact := range AllArtifacts {
+		if string(artifact) == name {
+			return true
+		}
+	}
+	return false
+}
+
 type TemplateModels struct {
-	RoutesFileTemplateModel    RoutesFileTemplateModel
-	ActionTemplateModels       []ActionTemplateModel
-	ServiceTemplateModels      []ServiceTemplateModel
-	ContractsFileTemplateModel ContractsFileTemplateModel
-	SchemasFileTemplateModel   SchemasFileTemplateModel
+	// Artifacts are the artifacts these models were generated for; models for anything else are left empty.
+	Artifacts                     ArtifactSet
+	RoutesFileTemplateModel       RoutesFileTemplateModel
+	BaseActionTemplateModels      []BaseActionTemplateModel
+	ActionTemplateModels          []ActionTemplateModel
+	ServiceTemplateModels         []ServiceTemplateModel
+	ContractsFileTemplateModels   []ContractsFileTemplateModel
+	SchemasFileTemplateModels     []SchemasFileTemplateModel
+	StructsFileTemplateModels     []StructsFileTemplateModel
+	SerializersFileTemplateModels []SerializersFileTemplateModel
+	RequestSpecTemplateModels     []RequestSpecTemplateModel
+	FactoryTemplateModels         []FactoryTemplateModel
+	ClientTemplateModels          []ClientTemplateModel
+	PersistenceTemplateModels     []PersistenceTemplateModel
+	MiddlewareTemplateModels      []MiddlewareTemplateModel
+}
+
+// artifacts are the artifacts being generated: the ones in g.Artifacts, or the defaults if none were selected.
+func (g Generator) artifacts() ArtifactSet {
+	if len(g.Artifacts) == 0 {
+		return NewArtifactSet(DefaultArtifacts...)
+	}
+
+	return g.Artifacts
 }
 
+// GenerateTemplateModels generates the template models for the artifacts in g.Artifacts, or for the defaults if
+// no artifacts were selected.
 func (g Generator) GenerateTemplateModels() (*TemplateModels, error) {
-	routesFileTemplateModel, err := g.GenerateRoutesFileTemplateModel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate routes file template model: %w\n", err)
+	artifacts := g.artifacts()
+
+	templateModels := &TemplateModels{Artifacts: artifacts}
+	var err error
+
+	if artifacts.Includes(ArtifactRoutes) {
+		templateModels.RoutesFileTemplateModel, err = g.GenerateRoutesFileTemplateModel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate routes file template model: %w\n", err)
+		}
 	}
 
-	actionTemplateModels, err := g.GenerateActionTemplateModels()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate action template models: %w\n", err)
+	if artifacts.Includes(ArtifactBaseAction) {
+		templateModels.BaseActionTemplateModels = g.GenerateBaseActionTemplateModels()
 	}
 
-	serviceTemplateModels, err := g.GenerateServiceTemplateModels()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate service template models: %w\n", err)
+	if artifacts.Includes(ArtifactActions) {
+		templateModels.ActionTemplateModels, err = g.GenerateActionTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate action template models: %w\n", err)
+		}
 	}
 
-	contractsFileTemplateModel, err := g.GenerateContractsFileTemplateModel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate contracts file template models: %w\n", err)
+	if artifacts.Includes(ArtifactServices) || artifacts.Includes(ArtifactMock) {
+		templateModels.ServiceTemplateModels, err = g.GenerateServiceTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate service template models: %w\n", err)
+		}
 	}
 
-	schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate schemas file template models: %w\n", err)
+	if artifacts.Includes(ArtifactContracts) {
+		templateModels.ContractsFileTemplateModels, err = g.GenerateContractsFileTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate contracts file template models: %w\n", err)
+		}
 	}
 
-	return &TemplateModels{
-		RoutesFileTemplateModel:    routesFileTemplateModel,
-		ActionTemplateModels:       actionTemplateModels,
-		ServiceTemplateModels:      serviceTemplateModels,
-		ContractsFileTemplateModel: contractsFileTemplateModel,
-		SchemasFileTemplateModel:   schemasFileTemplateModel,
-	}, nil
+	if artifacts.Includes(ArtifactSchemas) {
+		templateModels.SchemasFileTemplateModels, err = g.GenerateSchemasFileTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate schemas file template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactStructs) {
+		templateModels.StructsFileTemplateModels, err = g.GenerateStructsFileTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate structs file template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactSerializers) {
+		templateModels.SerializersFileTemplateModels, err = g.GenerateSerializersFileTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate serializers file template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactRequestSpecs) {
+		templateModels.RequestSpecTemplateModels, err = g.GenerateRequestSpecTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate request spec template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactFactories) {
+		templateModels.FactoryTemplateModels, err = g.GenerateFactoryTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate factory template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactClient) {
+		templateModels.ClientTemplateModels, err = g.GenerateClientTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate client template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactPersistence) {
+		templateModels.PersistenceTemplateModels, err = g.GeneratePersistenceTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate persistence template models: %w\n", err)
+		}
+	}
+
+	if artifacts.Includes(ArtifactMiddleware) {
+		templateModels.MiddlewareTemplateModels, err = g.GenerateMiddlewareTemplateModels()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate middleware template models: %w\n", err)
+		}
+	}
+
+	return templateModels, nil
 }
 
 type RoutesFileTemplateModel struct {
-	AppName   string
+	AppName string
+	Slices  []RoutesSliceTemplateModel
+}
+
+// RoutesSliceTemplateModel is a `slice ... do` block in routes.rb.
+type RoutesSliceTemplateModel struct {
 	SliceName string
+	At        string
 	Routes    []RouteTemplateModel
 }
 
@@ -213,26 +699,58 @@ type RouteTemplateModel struct {
 	ModuleName    string
 	OperationName string
 	Path          string
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
 func (g Generator) GenerateRoutesFileTemplateModel() (RoutesFileTemplateModel, error) {
-	var routeTemplateModels []RouteTemplateModel
-	for _, operationDefinition := range g.OperationDefinitions {
-		routeTemplateModels = append(routeTemplateModels, RouteTemplateModel{
-			Method:        operationDefinition.Method,
-			ModuleName:    operationDefinition.ModuleName,
-			OperationName: operationDefinition.OperationId,
-			Path:          toRackPath(operationDefinition.Path),
+	var sliceTemplateModels []RoutesSliceTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		var routeTemplateModels []RouteTemplateModel
+		for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+			routeTemplateModels = append(routeTemplateModels, RouteTemplateModel{
+				Method:        operationDefinition.Method,
+				ModuleName:    operationDefinition.ModuleName,
+				OperationName: operationDefinition.ActionName,
+				Path:          toRackPath(g.routePath(operationDefinition)),
+				Extensions:    decodedExtensions(operationDefinition.Spec.Extensions),
+			})
+		}
+
+		sliceTemplateModels = append(sliceTemplateModels, RoutesSliceTemplateModel{
+			SliceName: sliceName,
+			At:        g.sliceMount(sliceName),
+			Routes:    routeTemplateModels,
 		})
 	}
 
 	return RoutesFileTemplateModel{
-		AppName:   g.AppName,
-		SliceName: g.SliceName,
-		Routes:    routeTemplateModels,
+		AppName: g.AppName,
+		Slices:  sliceTemplateModels,
 	}, nil
 }
 
+// sliceMount is where a slice is mounted in routes.rb. A slice picked by path prefix is mounted at that prefix,
+// and its routes are relative to it, so the URLs come out the same as in the spec.
+func (g Generator) sliceMount(sliceName string) string {
+	if pathPrefix := strings.TrimSuffix(g.Slices[sliceName].PathPrefix, "/"); pathPrefix != "" {
+		return pathPrefix
+	}
+
+	return "/" + toSnake(sliceName)
+}
+
+// routePath is an operation's path within its slice's mount, see sliceMount.
+func (g Generator) routePath(operationDefinition OperationDefinition) string {
+	path := operationDefinition.Path
+	pathPrefix := strings.TrimSuffix(g.Slices[operationDefinition.SliceName].PathPrefix, "/")
+	if pathPrefix != "" && hasPathPrefix(path, pathPrefix) {
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, pathPrefix), "/")
+	}
+
+	return path
+}
+
 // toRackPath converts a path definition as given by OpenAPI spec to something Rack understands.
 // For example "/users/{user_id}" -> "/users/:user_id"
 func toRackPath(codegenPath string) string {
@@ -241,26 +759,100 @@ func toRackPath(codegenPath string) string {
 	return out
 }
 
+type BaseActionTemplateModel struct {
+	AppName     string
+	SliceName   string
+	ModuleName  string
+	ClassName   string
+	ParentClass string
+}
+
+// GenerateBaseActionTemplateModels describes the classes generated actions inherit from.
+// In the flat layout that's a single <App>::BaseAction. In the hanami layout each slice gets its own
+// <Slice>::Action, which inherits from the app's <App>::Action the same way `hanami generate slice` sets things up.
+func (g Generator) GenerateBaseActionTemplateModels() []BaseActionTemplateModel {
+	if g.Layout != LayoutHanami {
+		return []BaseActionTemplateModel{g.baseActionTemplateModel(g.SliceName)}
+	}
+
+	var baseActionTemplateModels []BaseActionTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		baseActionTemplateModels = append(baseActionTemplateModels, g.baseActionTemplateModel(sliceName))
+	}
+
+	return baseActionTemplateModels
+}
+
+func (g Generator) baseActionTemplateModel(sliceName string) BaseActionTemplateModel {
+	if g.Layout == LayoutHanami {
+		return BaseActionTemplateModel{
+			AppName:     g.AppName,
+			SliceName:   sliceName,
+			ModuleName:  sliceName,
+			ClassName:   "Action",
+			ParentClass: fmt.Sprintf("%s::Action", g.AppName),
+		}
+	}
+
+	return BaseActionTemplateModel{
+		AppName:     g.AppName,
+		SliceName:   sliceName,
+		ModuleName:  g.AppName,
+		ClassName:   "BaseAction",
+		ParentClass: "Hanami::Action",
+	}
+}
+
+func (m BaseActionTemplateModel) QualifiedClassName() string {
+	return fmt.Sprintf("%s::%s", m.ModuleName, m.ClassName)
+}
+
 type ActionTemplateModel struct {
-	AppName    string
-	SliceName  string
-	ActionName string
-	ModuleName string
+	AppName         string
+	SliceName       string
+	ActionName      string
+	ModuleName      string
+	BaseActionClass string
+	// RequestContract and ResponseContract are the names of the operation's contracts in contracts.rb.
+	RequestContract  string
+	ResponseContract string
+	// ServiceKey is the container key of the service the action calls, see OperationDefinition.ServiceKey.
+	ServiceKey string
+	// Before lists the methods the action calls before handling a request, from ExtensionBefore.
+	Before []string
+	// RequestStruct is the struct in structs.rb the action makes the validated params into for its service, or empty
+	// if it hands the service a hash, see Generator.requestStructName.
+	RequestStruct string
+	// ResponseSerializer is the serializer in serializers.rb the action writes the response body with, or empty if
+	// it writes out the validated response as it is.
+	ResponseSerializer string
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
-func NewActionTemplateModel(appName string, sliceName string, operationDefinition OperationDefinition) ActionTemplateModel {
+func NewActionTemplateModel(appName string, sliceName string, baseActionClass string, operationDefinition OperationDefinition) ActionTemplateModel {
 	return ActionTemplateModel{
-		AppName:    appName,
-		SliceName:  sliceName,
-		ActionName: operationDefinition.OperationId,
-		ModuleName: operationDefinition.ModuleName,
+		AppName:          appName,
+		SliceName:        sliceName,
+		ActionName:       operationDefinition.ActionName,
+		ModuleName:       operationDefinition.ModuleName,
+		BaseActionClass:  baseActionClass,
+		RequestContract:  requestContractName(operationDefinition),
+		ResponseContract: responseContractName(operationDefinition),
+		ServiceKey:       operationDefinition.ServiceKey(),
+		Before:           operationDefinition.HanamiExtensions.Before,
+		Extensions:       decodedExtensions(operationDefinition.Spec.Extensions),
 	}
 }
 
 func (g Generator) GenerateActionTemplateModels() ([]ActionTemplateModel, error) {
 	var actionTemplateModels []ActionTemplateModel
 	for _, operationDefinition := range g.OperationDefinitions {
-		actionTemplateModels = append(actionTemplateModels, NewActionTemplateModel(g.AppName, g.SliceName, operationDefinition))
+		baseActionClass := g.baseActionTemplateModel(operationDefinition.SliceName).QualifiedClassName()
+		actionTemplateModel := NewActionTemplateModel(g.AppName, operationDefinition.SliceName, baseActionClass, operationDefinition)
+		actionTemplateModel.RequestStruct = g.requestStructName(operationDefinition)
+		actionTemplateModel.ResponseSerializer = g.responseSerializerName(operationDefinition)
+		actionTemplateModels = append(actionTemplateModels, actionTemplateModel)
 	}
 
 	return actionTemplateModels, nil
@@ -271,60 +863,116 @@ type ServiceTemplateModel struct {
 	SliceName   string
 	ServiceName string
 	ModuleName  string
+	// RequestStruct is the struct in structs.rb the service is called with, or empty if it's called with a hash.
+	RequestStruct string
+	// MockResponse is a Ruby literal of the response body a mock service returns, or empty if it isn't a mock.
+	MockResponse string
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
 func NewServiceTemplateModel(appName string, sliceName string, operationDefinition OperationDefinition) ServiceTemplateModel {
 	return ServiceTemplateModel{
 		AppName:     appName,
 		SliceName:   sliceName,
-		ServiceName: fmt.Sprintf("%s", operationDefinition.OperationId),
+		ServiceName: operationDefinition.ActionName,
 		ModuleName:  operationDefinition.ModuleName,
+		Extensions:  decodedExtensions(operationDefinition.Spec.Extensions),
 	}
 }
 
 func (g Generator) GenerateServiceTemplateModels() ([]ServiceTemplateModel, error) {
 	var serviceTemplateModels []ServiceTemplateModel
 	for _, operationDefinition := range g.OperationDefinitions {
-		serviceTemplateModels = append(serviceTemplateModels, NewServiceTemplateModel(g.AppName, g.SliceName, operationDefinition))
+		// the action calls an existing service instead
+		if operationDefinition.HanamiExtensions.Service != "" {
+			continue
+		}
+		serviceTemplateModel := NewServiceTemplateModel(g.AppName, operationDefinition.SliceName, operationDefinition)
+		serviceTemplateModel.RequestStruct = g.requestStructName(operationDefinition)
+		if g.artifacts().Includes(ArtifactMock) {
+			serviceTemplateModel.MockResponse = g.mockResponse(operationDefinition)
+		}
+		serviceTemplateModels = append(serviceTemplateModels, serviceTemplateModel)
 	}
 
 	return serviceTemplateModels, nil
 }
 
+// ServiceKey is the container key of the service the operation's action calls: the one generated for it, or an
+// existing one given by ExtensionService.
+func (o OperationDefinition) ServiceKey() string {
+	if o.HanamiExtensions.Service != "" {
+		return o.HanamiExtensions.Service
+	}
+	return fmt.Sprintf("services.%s.%s", moduleKey(o.ModuleName), toSnake(o.ActionName))
+}
+
+func requestContractName(operationDefinition OperationDefinition) string {
+	return fmt.Sprintf("%sRequestContract", operationDefinition.OperationId)
+}
+
+func responseContractName(operationDefinition OperationDefinition) string {
+	return fmt.Sprintf("%sResponseContract", operationDefinition.OperationId)
+}
+
 type ContractTemplateModel struct {
 	ContractName string
 	BaseClass    string
-	Attributes   []AttributeDefinition
+	// Request is set for request contracts, which Hanami validates params with, as opposed to response contracts.
+	Request    bool
+	Attributes []AttributeDefinition
+	// Keys are the keys the contract renames before validating them, from their names in the spec to what services
+	// call them, see KeyTemplateModel.
+	Keys []KeyTemplateModel
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
 type ContractsFileTemplateModel struct {
 	AppName   string
 	SliceName string
+	// KeyMaps are the component schemas' KeyMaps, which the contracts' keys refer to.
+	KeyMaps   []KeyMapTemplateModel
 	Contracts []ContractTemplateModel
 }
 
-func (g Generator) GenerateContractsFileTemplateModel() (ContractsFileTemplateModel, error) {
-	var contracts []ContractTemplateModel
-	for _, operationDefinition := range g.OperationDefinitions {
-		requestContract := ContractTemplateModel{
-			ContractName: fmt.Sprintf("%sRequestContract", operationDefinition.OperationId),
-			BaseClass:    "Hanami::Action::Params",
+// GenerateContractsFileTemplateModels generates a contracts file for each slice.
+func (g Generator) GenerateContractsFileTemplateModels() ([]ContractsFileTemplateModel, error) {
+	var contractsFileTemplateModels []ContractsFileTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		contractsFileTemplateModel, err := g.GenerateContractsFileTemplateModel(sliceName)
+		if err != nil {
+			return nil, err
 		}
+		contractsFileTemplateModels = append(contractsFileTemplateModels, contractsFileTemplateModel)
+	}
 
-		// injecting the request body attributes
-		if operationDefinition.Spec.RequestBody != nil {
-			requestContract.Attributes = generateAttributeDefinitions(operationDefinition.RequestBodySchema)
-		}
+	return contractsFileTemplateModels, nil
+}
+
+// GenerateContractsFileTemplateModel generates the request and response contracts for the operations in a slice.
+func (g Generator) GenerateContractsFileTemplateModel(sliceName string) (ContractsFileTemplateModel, error) {
+	var contracts []ContractTemplateModel
+	for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+		extensions := decodedExtensions(operationDefinition.Spec.Extensions)
 
-		// injecting the query & path params
-		for _, pathParam := range operationDefinition.Spec.Parameters {
-			requestContract.Attributes = append(requestContract.Attributes, generateAttributeDefinition(pathParam.Value.Name, pathParam.Value.Schema, pathParam.Value.Required))
+		requestContract := ContractTemplateModel{
+			ContractName: requestContractName(operationDefinition),
+			BaseClass:    "Hanami::Action::Params",
+			Request:      true,
+			Attributes:   g.requestAttributeDefinitions(operationDefinition),
+			Keys:         g.requestKeys(operationDefinition),
+			Extensions:   extensions,
 		}
 
+		// response contracts rename keys too, so they can check response bodies as well as what services return
 		responseContract := ContractTemplateModel{
-			ContractName: fmt.Sprintf("%sResponseContract", operationDefinition.OperationId),
+			ContractName: responseContractName(operationDefinition),
 			BaseClass:    "Dry::Validation::Contract",
-			Attributes:   generateAttributeDefinitions(operationDefinition.ResponseBody200Schema),
+			Attributes:   g.generateAttributeDefinitions(operationDefinition.ResponseBody200Schema),
+			Keys:         g.keyMapKeys(operationDefinition.ResponseBody200Schema, g.schemasWithRenamedKeys(), nil),
+			Extensions:   extensions,
 		}
 
 		contracts = append(contracts, requestContract, responseContract)
@@ -332,14 +980,50 @@ func (g Generator) GenerateContractsFileTemplateModel() (ContractsFileTemplateMo
 
 	return ContractsFileTemplateModel{
 		AppName:   g.AppName,
-		SliceName: g.SliceName,
+		SliceName: sliceName,
+		KeyMaps:   g.GenerateKeyMapTemplateModels(),
 		Contracts: contracts,
 	}, nil
 }
 
+// requestAttributeDefinitions are the attributes of an operation's request: its body's properties, then its query
+// and path params.
+func (g Generator) requestAttributeDefinitions(operationDefinition OperationDefinition) []AttributeDefinition {
+	var attributeDefinitions []AttributeDefinition
+
+	// injecting the request body attributes
+	if operationDefinition.Spec.RequestBody != nil {
+		attributeDefinitions = g.generateAttributeDefinitions(operationDefinition.RequestBodySchema)
+	}
+
+	// injecting the query & path params
+	for _, pathParam := range operationDefinition.Spec.Parameters {
+		// extensions can go on the parameter as well as its schema, and the parameter's win
+		parameterExtensions, _ := readSchemaExtensions(pathParam.Value.Extensions)
+		if parameterExtensions.Skip {
+			continue
+		}
+		attributeDefinition, ok := g.generateAttributeDefinition(pathParam.Value.Name, pathParam.Value.Schema, pathParam.Value.Required)
+		if !ok {
+			continue
+		}
+		if parameterExtensions.RubyType != "" {
+			attributeDefinition.AttributeType = parameterExtensions.RubyType
+		}
+		if extensions := decodedExtensions(pathParam.Value.Extensions); extensions != nil {
+			attributeDefinition.Extensions = merge(merge(map[string]any{}, attributeDefinition.Extensions), extensions)
+		}
+		attributeDefinitions = append(attributeDefinitions, attributeDefinition)
+	}
+
+	return attributeDefinitions
+}
+
 type SchemaTemplateModel struct {
 	SchemaName string
 	Attributes []AttributeDefinition
+	// Extensions are the schema's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
 type SchemasFileTemplateModel struct {
@@ -348,13 +1032,34 @@ type SchemasFileTemplateModel struct {
 	Schemas   []SchemaTemplateModel
 }
 
-func (g Generator) GenerateSchemasFileTemplateModel() (SchemasFileTemplateModel, error) {
+// GenerateSchemasFileTemplateModels generates a schemas file for each slice. Component schemas are shared by the
+// whole spec, so every slice gets all of them.
+func (g Generator) GenerateSchemasFileTemplateModels() ([]SchemasFileTemplateModel, error) {
+	var schemasFileTemplateModels []SchemasFileTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel(sliceName)
+		if err != nil {
+			return nil, err
+		}
+		schemasFileTemplateModels = append(schemasFileTemplateModels, schemasFileTemplateModel)
+	}
+
+	return schemasFileTemplateModels, nil
+}
+
+func (g Generator) GenerateSchemasFileTemplateModel(sliceName string) (SchemasFileTemplateModel, error) {
 	var schemas []SchemaTemplateModel
 
-	for key, value := range g.Swagger.Components.Schemas {
+	for _, key := range schemaDefinitionOrder(g.Schemas) {
+		schemaExtensions, _ := readSchemaExtensions(g.Schemas[key].Value.Extensions)
+		if schemaExtensions.Skip {
+			continue
+		}
+
 		schemaTemplateModel := SchemaTemplateModel{
 			SchemaName: key,
-			Attributes: generateAttributeDefinitions(value),
+			Attributes: g.generateAttributeDefinitions(g.Schemas[key]),
+			Extensions: decodedExtensions(g.Schemas[key].Value.Extensions),
 		}
 
 		schemas = append(schemas, schemaTemplateModel)
@@ -362,21 +1067,87 @@ func (g Generator) GenerateSchemasFileTemplateModel() (SchemasFileTemplateModel,
 
 	return SchemasFileTemplateModel{
 		AppName:   g.AppName,
-		SliceName: g.SliceName,
+		SliceName: sliceName,
 		Schemas:   schemas,
 	}, nil
 }
 
+// schemaDefinitionOrder sorts schema names alphabetically, except that schemas come after the ones they
+// reference, since Ruby constants have to be defined before they're used.
+func schemaDefinitionOrder(schemas openapi3.Schemas) []string {
+	var order []string
+	visited := map[string]bool{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+
+		schemaRef, ok := schemas[name]
+		if !ok {
+			return
+		}
+		for _, referenced := range referencedSchemaNames(schemaRef.Value, map[*openapi3.Schema]bool{}) {
+			visit(referenced)
+		}
+		order = append(order, name)
+	}
+
+	for _, name := range sortedKeys(schemas) {
+		visit(name)
+	}
+
+	return order
+}
+
+// referencedSchemaNames lists the component schemas schema refers to, sorted.
+func referencedSchemaNames(schema *openapi3.Schema, seen map[*openapi3.Schema]bool) []string {
+	if schema == nil || seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+
+	var schemaRefs []*openapi3.SchemaRef
+	for _, name := range sortedKeys(schema.Properties) {
+		schemaRefs = append(schemaRefs, schema.Properties[name])
+	}
+	if schema.Items != nil {
+		schemaRefs = append(schemaRefs, schema.Items)
+	}
+
+	var names []string
+	for _, schemaRef := range schemaRefs {
+		if strings.HasPrefix(schemaRef.Ref, componentSchemasRefPrefix) {
+			names = append(names, strings.TrimPrefix(schemaRef.Ref, componentSchemasRefPrefix))
+		} else {
+			names = append(names, referencedSchemaNames(schemaRef.Value, seen)...)
+		}
+	}
+
+	return names
+}
+
 type AttributeDefinition struct {
-	AttributeName    string
-	AttributeType    string
+	// AttributeName is the property's, or param's, name in the spec, and Key what services call it, see
+	// Generator.serviceKey. Keys are renamed by the contracts as they're validated.
+	AttributeName string
+	Key           string
+	AttributeType string
+	// Verb is the dry-schema macro the attribute is defined with: value, maybe (for nullable values) or array.
 	Verb             string
 	HasChildren      bool
 	NestedAttributes []AttributeDefinition
 	Required         bool
+	// Predicates are extra dry-schema predicates for the value, e.g. `included_in?: ["available", "sold"]` for an
+	// enum.
+	Predicates []string
+	// Extensions are the property's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
 }
 
-func generateAttributeDefinitions(schemaRef *openapi3.SchemaRef) []AttributeDefinition {
+func (g Generator) generateAttributeDefinitions(schemaRef *openapi3.SchemaRef) []AttributeDefinition {
 	if schemaRef == nil {
 		return nil
 	}
@@ -392,8 +1163,10 @@ func generateAttributeDefinitions(schemaRef *openapi3.SchemaRef) []AttributeDefi
 
 	for _, propertyKey := range sortedKeys {
 		propertyValue := schemaRef.Value.Properties[propertyKey]
-		attributeDefinition := generateAttributeDefinition(propertyKey, propertyValue, isInArray(schemaRef.Value.Required, propertyKey))
-		attributeDefinitions = append(attributeDefinitions, attributeDefinition)
+		attributeDefinition, ok := g.generateAttributeDefinition(propertyKey, propertyValue, isInArray(schemaRef.Value.Required, propertyKey))
+		if ok {
+			attributeDefinitions = append(attributeDefinitions, attributeDefinition)
+		}
 	}
 
 	return attributeDefinitions
@@ -409,9 +1182,12 @@ func sortedSchemaRefPropertyKeys(schemaRef *openapi3.SchemaRef) []string {
 	return sortedKeys
 }
 
-func generateAttributeDefinition(key string, schemaRef *openapi3.SchemaRef, required bool) AttributeDefinition {
+// generateAttributeDefinition maps the schema of a property (or parameter, or array items) onto dry-schema. It
+// reports false if the property is skipped with ExtensionSkip, or refers to a schema that is.
+func (g Generator) generateAttributeDefinition(key string, schemaRef *openapi3.SchemaRef, required bool) (AttributeDefinition, bool) {
 	attributeDefinition := AttributeDefinition{
 		AttributeName:    key,
+		Key:              g.serviceKey(key),
 		AttributeType:    "",
 		Verb:             "",
 		HasChildren:      false,
@@ -419,48 +1195,154 @@ func generateAttributeDefinition(key string, schemaRef *openapi3.SchemaRef, requ
 		Required:         required,
 	}
 
+	// refs are resolved, so a ref'd schema's extensions apply wherever it's used
+	schemaExtensions, _ := readSchemaExtensions(schemaRef.Value.Extensions)
+	if schemaExtensions.Skip {
+		return attributeDefinition, false
+	}
+	attributeDefinition.Extensions = decodedExtensions(schemaRef.Value.Extensions)
+
+	if schemaExtensions.RubyType != "" {
+		attributeDefinition.AttributeType = schemaExtensions.RubyType
+		attributeDefinition.Verb = valueVerb(schemaRef.Value)
+		attributeDefinition.Predicates = enumPredicates(schemaRef.Value.Enum)
+		return attributeDefinition, true
+	}
+
 	if isRef(schemaRef) {
 		attributeDefinition.AttributeType = referencedSchemaType(schemaRef)
-		return attributeDefinition
+		return attributeDefinition, true
 	}
 
 	propertyType := schemaRef.Value.Type
 	switch propertyType {
-	case "string":
-		attributeDefinition.Verb = "value"
-
-		if schemaRef.Value.Format == "uuid" {
-			attributeDefinition.AttributeType = ":uuid_v4?"
-		} else {
-			attributeDefinition.AttributeType = ":string"
-		}
-	case "integer":
-		attributeDefinition.AttributeType = ":integer"
-		attributeDefinition.Verb = "value"
+	case "string", "integer", "number", "boolean":
+		attributeDefinition.AttributeType = g.scalarAttributeType(propertyType, schemaRef.Value.Format)
+		attributeDefinition.Verb = valueVerb(schemaRef.Value)
+		attributeDefinition.Predicates = enumPredicates(schemaRef.Value.Enum)
 	case "array":
 		attributeDefinition.Verb = "array"
-		itemsAttributeDefinition := generateAttributeDefinition("", schemaRef.Value.Items, isInArray(schemaRef.Value.Required, key))
+		itemsAttributeDefinition, ok := g.generateAttributeDefinition("", schemaRef.Value.Items, isInArray(schemaRef.Value.Required, key))
+		if !ok {
+			return attributeDefinition, false
+		}
 		attributeDefinition.AttributeType = itemsAttributeDefinition.AttributeType
 		attributeDefinition.NestedAttributes = itemsAttributeDefinition.NestedAttributes
 		attributeDefinition.HasChildren = len(itemsAttributeDefinition.NestedAttributes) > 0
 	case "object":
 		attributeDefinition.AttributeType = ":hash"
-		attributeDefinition.Verb = "value"
+		attributeDefinition.Verb = valueVerb(schemaRef.Value)
 		attributeDefinition.HasChildren = true
-		attributeDefinition.NestedAttributes = generateAttributeDefinitions(schemaRef)
+		attributeDefinition.NestedAttributes = g.generateAttributeDefinitions(schemaRef)
 	}
 
-	return attributeDefinition
+	return attributeDefinition, true
+}
+
+// valueVerb is maybe for nullable schemas, which dry-schema lets be nil, and value otherwise.
+func valueVerb(schema *openapi3.Schema) string {
+	if schema.Nullable {
+		return "maybe"
+	}
+	return "value"
+}
+
+// enumPredicates restricts a value to the schema's enum, if it has one. A single value, e.g. from an OpenAPI 3.1
+// const, is checked with eql?.
+func enumPredicates(enum []interface{}) []string {
+	var values []string
+	for _, value := range enum {
+		// null is allowed by nullable, which decides the verb
+		if value != nil {
+			values = append(values, rubyLiteral(value))
+		}
+	}
+
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return []string{"eql?: " + values[0]}
+	default:
+		return []string{"included_in?: [" + strings.Join(values, ", ") + "]"}
+	}
+}
+
+// rubyLiteral writes a value from a spec, e.g. an enum value or an example, as Ruby. Objects become hashes with
+// string keys, the way JSON.parse would read them.
+func rubyLiteral(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		// double quoted strings are the same in Go and Ruby, bar interpolation
+		return strings.ReplaceAll(strconv.Quote(value), "#{", `\#{`)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		return "nil"
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = rubyLiteral(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return "{}"
+		}
+		pairs := make([]string, 0, len(value))
+		for _, key := range sortedKeys(value) {
+			pairs = append(pairs, rubyLiteral(key)+" => "+rubyLiteral(value[key]))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// defaultTypeMappings maps OpenAPI scalar types, or "type:format" pairs, to dry-types types.
+// They can be overridden with Config.TypeMappings.
+var defaultTypeMappings = map[string]string{
+	"string":      ":string",
+	"string:uuid": ":uuid_v4?",
+	"integer":     ":integer",
+	"number":      ":float",
+	"boolean":     ":bool",
+}
+
+// scalarAttributeType looks up the dry-types type for an OpenAPI type and format, preferring the most
+// specific match ("type:format" over "type"), and configured mappings over the defaults.
+func (g Generator) scalarAttributeType(openapiType string, format string) string {
+	for _, mappings := range []map[string]string{g.TypeMappings, defaultTypeMappings} {
+		if format != "" {
+			if attributeType, ok := mappings[openapiType+":"+format]; ok {
+				return attributeType
+			}
+		}
+		if attributeType, ok := mappings[openapiType]; ok {
+			return attributeType
+		}
+	}
+
+	return ""
 }
 
 func isRef(propertyValue *openapi3.SchemaRef) bool {
 	return propertyValue.Ref != ""
 }
 
+// referencedSchemaType is the constant a $ref'd schema is defined as in schemas.rb, which names schemas after
+// their key in components/schemas.
 func referencedSchemaType(schemaRef *openapi3.SchemaRef) string {
-	return fmt.Sprintf("Schemas::%s", schemaRef.Value.Title)
+	schemaName := strings.TrimPrefix(schemaRef.Ref, componentSchemasRefPrefix)
+	if schemaName == schemaRef.Ref {
+		schemaName = schemaRef.Value.Title
+	}
+
+	return fmt.Sprintf("Schemas::%s", schemaName)
 }
 
+var componentSchemasRefPrefix = "#/components/schemas/"
+
 func isInArray(arr []string, val string) bool {
 	for _, el := range arr {
 		if el == val {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -49,6 +50,22 @@ func TestNewGenerator_MissingTags(t *testing.T) {
 	assert.ErrorContains(t, err, ErrMissingTags.Error())
 }
 
+func TestNewGenerator_InvalidSpec(t *testing.T) {
+	_, err := NewGenerator("fixtures/test_spec_invalid.yaml", "TestApp", "API")
+	assert.ErrorContains(t, err, "error validating swagger spec")
+}
+
+func TestNewGenerator_MissingOperationIds(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_no_operation_ids.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	assert.Equal(t, "GetBooksBookId", g.OperationDefinitions[0].OperationId)
+	// schemas that no operation uses are still generated
+	assert.Contains(t, g.Schemas, "Unused")
+}
+
 func TestGenerator_GenerateRoutesFileTemplateModel(t *testing.T) {
 	g, err := NewGenerator("fixtures/test_spec.yaml", "TestApp", "API")
 	if err != nil {
@@ -62,18 +79,24 @@ func TestGenerator_GenerateRoutesFileTemplateModel(t *testing.T) {
 
 	expected := RoutesFileTemplateModel{
 		AppName: "TestApp",
-		Routes: []RouteTemplateModel{
+		Slices: []RoutesSliceTemplateModel{
 			{
-				Method:        "GET",
-				ModuleName:    "books",
-				OperationName: "GetBooks",
-				Path:          "/books",
-			},
-			{
-				Method:        "GET",
-				ModuleName:    "books",
-				OperationName: "GetBookById",
-				Path:          "/books/:bookId",
+				SliceName: "API",
+				At:        "/api",
+				Routes: []RouteTemplateModel{
+					{
+						Method:        "GET",
+						ModuleName:    "books",
+						OperationName: "GetBooks",
+						Path:          "/books",
+					},
+					{
+						Method:        "GET",
+						ModuleName:    "books",
+						OperationName: "GetBookById",
+						Path:          "/books/:bookId",
+					},
+				},
 			},
 		},
 	}
@@ -94,16 +117,26 @@ func TestGenerator_GenerateActionTemplateModels(t *testing.T) {
 
 	expectedActionTemplateModels := []ActionTemplateModel{
 		{
-			AppName:    "TestApp",
-			SliceName:  "API",
-			ActionName: "GetBookById",
-			ModuleName: "books",
+			AppName:            "TestApp",
+			SliceName:          "API",
+			ActionName:         "GetBookById",
+			ModuleName:         "books",
+			BaseActionClass:    "TestApp::BaseAction",
+			RequestContract:    "GetBookByIdRequestContract",
+			ResponseContract:   "GetBookByIdResponseContract",
+			ServiceKey:         "services.books.get_book_by_id",
+			ResponseSerializer: "GetBookById",
 		},
 		{
-			AppName:    "TestApp",
-			SliceName:  "API",
-			ActionName: "GetBooks",
-			ModuleName: "books",
+			AppName:            "TestApp",
+			SliceName:          "API",
+			ActionName:         "GetBooks",
+			ModuleName:         "books",
+			BaseActionClass:    "TestApp::BaseAction",
+			RequestContract:    "GetBooksRequestContract",
+			ResponseContract:   "GetBooksResponseContract",
+			ServiceKey:         "services.books.get_books",
+			ResponseSerializer: "GetBooks",
 		},
 	}
 
@@ -145,7 +178,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 		t.Fatalf("error creating generator: %s\n", err)
 	}
 
-	model, err := g.GenerateContractsFileTemplateModel()
+	model, err := g.GenerateContractsFileTemplateModel("API")
 	if err != nil {
 		t.Fatalf("error generating contracts file: %s\n", err)
 	}
@@ -157,6 +190,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 			{
 				ContractName: "GetBooksRequestContract",
 				BaseClass:    "Hanami::Action::Params",
+				Request:      true,
 				Attributes:   nil,
 			},
 			{
@@ -165,12 +199,14 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 				Attributes: []AttributeDefinition{
 					{
 						AttributeName: "books",
+						Key:           "books",
 						AttributeType: ":hash",
 						Verb:          "array",
 						HasChildren:   true,
 						NestedAttributes: []AttributeDefinition{
 							{
 								AttributeName:    "author",
+								Key:              "author",
 								AttributeType:    ":string",
 								Verb:             "value",
 								HasChildren:      false,
@@ -179,6 +215,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 							},
 							{
 								AttributeName:    "title",
+								Key:              "title",
 								AttributeType:    ":string",
 								Verb:             "value",
 								HasChildren:      false,
@@ -193,6 +230,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 			{
 				ContractName: "GetBookByIdRequestContract",
 				BaseClass:    "Hanami::Action::Params",
+				Request:      true,
 				Attributes:   nil,
 			},
 			{
@@ -201,6 +239,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 				Attributes: []AttributeDefinition{
 					{
 						AttributeName:    "author",
+						Key:              "author",
 						AttributeType:    ":string",
 						Verb:             "value",
 						HasChildren:      false,
@@ -209,12 +248,14 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 					},
 					{
 						AttributeName: "avatar",
+						Key:           "avatar",
 						AttributeType: ":hash",
 						Verb:          "value",
 						HasChildren:   true,
 						NestedAttributes: []AttributeDefinition{
 							{
 								AttributeName:    "id",
+								Key:              "id",
 								AttributeType:    ":integer",
 								Verb:             "value",
 								HasChildren:      false,
@@ -223,6 +264,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 							},
 							{
 								AttributeName:    "profileImageUrl",
+								Key:              "profile_image_url",
 								AttributeType:    ":string",
 								Verb:             "value",
 								HasChildren:      false,
@@ -234,12 +276,14 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 					},
 					{
 						AttributeName: "reviews",
+						Key:           "reviews",
 						AttributeType: ":hash",
 						Verb:          "array",
 						HasChildren:   true,
 						NestedAttributes: []AttributeDefinition{
 							{
 								AttributeName:    "rating",
+								Key:              "rating",
 								AttributeType:    ":integer",
 								Verb:             "value",
 								HasChildren:      false,
@@ -248,6 +292,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 							},
 							{
 								AttributeName:    "text",
+								Key:              "text",
 								AttributeType:    ":string",
 								Verb:             "value",
 								HasChildren:      false,
@@ -256,12 +301,14 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 							},
 							{
 								AttributeName: "user",
+								Key:           "user",
 								AttributeType: ":hash",
 								Verb:          "value",
 								HasChildren:   true,
 								NestedAttributes: []AttributeDefinition{
 									{
 										AttributeName:    "id",
+										Key:              "id",
 										AttributeType:    ":string",
 										Verb:             "value",
 										HasChildren:      false,
@@ -270,6 +317,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 									},
 									{
 										AttributeName:    "name",
+										Key:              "name",
 										AttributeType:    ":string",
 										Verb:             "value",
 										HasChildren:      false,
@@ -284,6 +332,7 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 					},
 					{
 						AttributeName:    "title",
+						Key:              "title",
 						AttributeType:    ":string",
 						Verb:             "value",
 						HasChildren:      false,
@@ -291,6 +340,11 @@ func TestGenerator_GenerateContractsFileTemplateModel(t *testing.T) {
 						Required:         true,
 					},
 				},
+				Keys: []KeyTemplateModel{
+					{Name: "avatar", Key: "avatar", NestedKeys: []KeyTemplateModel{
+						{Name: "profileImageUrl", Key: "profile_image_url"},
+					}},
+				},
 			},
 		},
 	}
@@ -304,7 +358,7 @@ func TestGenerator_GenerateSchemasFileTemplateModel(t *testing.T) {
 		t.Fatalf("error creating generator: %s\n", err)
 	}
 
-	schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel()
+	schemasFileTemplateModel, err := g.GenerateSchemasFileTemplateModel("API")
 	if err != nil {
 		t.Fatalf("error generating schemas file template model: %s\n", err)
 	}
@@ -317,3 +371,134 @@ func TestGenerator_GenerateSchemasFileTemplateModel(t *testing.T) {
 
 	assert.Equal(t, expected, schemasFileTemplateModel)
 }
+
+func Test_schemaDefinitionOrder(t *testing.T) {
+	schemas := openapi3.Schemas{
+		"Author": openapi3.NewSchemaRef("", openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())),
+		"Book": openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+			WithPropertyRef("author", openapi3.NewSchemaRef("#/components/schemas/Author", nil)).
+			WithPropertyRef("series", openapi3.NewSchemaRef("#/components/schemas/Series", nil))),
+		"Series": openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+			WithProperty("books", openapi3.NewArraySchema().WithItems(openapi3.NewObjectSchema().
+				WithPropertyRef("publisher", openapi3.NewSchemaRef("#/components/schemas/Publisher", nil))))),
+		"Publisher": openapi3.NewSchemaRef("", openapi3.NewObjectSchema()),
+	}
+
+	assert.Equal(t, []string{"Author", "Publisher", "Series", "Book"}, schemaDefinitionOrder(schemas))
+}
+
+func TestGenerator_GenerateTemplateModels_SelectedArtifacts(t *testing.T) {
+	g, err := NewGeneratorFromConfig(&Config{
+		Input:     "fixtures/test_spec.yaml",
+		AppName:   "TestApp",
+		SliceName: "API",
+		Generate:  []string{"contracts"},
+	})
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	templateModels, err := g.GenerateTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating template models: %s\n", err)
+	}
+
+	assert.Equal(t, NewArtifactSet(ArtifactContracts), templateModels.Artifacts)
+	assert.Len(t, templateModels.ContractsFileTemplateModels, 1)
+	assert.Len(t, templateModels.ContractsFileTemplateModels[0].Contracts, 4)
+	assert.Empty(t, templateModels.ActionTemplateModels)
+	assert.Empty(t, templateModels.ServiceTemplateModels)
+	assert.Empty(t, templateModels.RoutesFileTemplateModel.Slices)
+
+	w, err := NewWriter(t.TempDir(), "TestApp", "", LayoutFlat)
+	if err != nil {
+		t.Fatalf("error creating writer: %s\n", err)
+	}
+
+	files, err := w.RenderFilesFromTemplateModels(templateModels)
+	if err != nil {
+		t.Fatalf("error rendering files: %s\n", err)
+	}
+
+	assert.Len(t, files, 1)
+	assert.Equal(t, w.ContractsFilePath(templateModels.ContractsFileTemplateModels[0]), files[0].Path)
+}
+
+func TestGenerator_MultipleSlices(t *testing.T) {
+	g, err := NewGeneratorFromConfig(&Config{
+		Input:     "fixtures/test_spec_slices.yaml",
+		AppName:   "TestApp",
+		SliceName: "API",
+		Layout:    LayoutHanami,
+		Slices: map[string]SliceConfig{
+			"Admin":     {PathPrefix: "/admin"},
+			"Warehouse": {Tags: []string{"inventory"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	assert.Equal(t, []string{"API", "Admin", "Internal", "Warehouse"}, g.SliceNames())
+
+	model, err := g.GenerateRoutesFileTemplateModel()
+	if err != nil {
+		t.Fatalf("error generating routes template model: %s\n", err)
+	}
+
+	expected := RoutesFileTemplateModel{
+		AppName: "TestApp",
+		Slices: []RoutesSliceTemplateModel{
+			{
+				SliceName: "API",
+				At:        "/api",
+				Routes: []RouteTemplateModel{
+					{Method: "GET", ModuleName: "books", OperationName: "GetBooks", Path: "/books"},
+				},
+			},
+			{
+				SliceName: "Admin",
+				At:        "/admin",
+				Routes: []RouteTemplateModel{
+					{Method: "GET", ModuleName: "users", OperationName: "GetUser", Path: "/users/:userId"},
+				},
+			},
+			{
+				SliceName: "Internal",
+				At:        "/internal",
+				Routes: []RouteTemplateModel{
+					{Method: "GET", ModuleName: "users", OperationName: "GetReports", Path: "/reports", Extensions: map[string]any{"x-hanami-slice": "Internal"}},
+				},
+			},
+			{
+				SliceName: "Warehouse",
+				At:        "/warehouse",
+				Routes: []RouteTemplateModel{
+					{Method: "GET", ModuleName: "inventory", OperationName: "GetStock", Path: "/stock"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, model)
+
+	contractsFileTemplateModels, err := g.GenerateContractsFileTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating contracts file template models: %s\n", err)
+	}
+	assert.Len(t, contractsFileTemplateModels, 4)
+	assert.Equal(t, "Admin", contractsFileTemplateModels[1].SliceName)
+	assert.Equal(t, "GetUserRequestContract", contractsFileTemplateModels[1].Contracts[0].ContractName)
+
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+	for _, actionTemplateModel := range actionTemplateModels {
+		assert.Equal(t, actionTemplateModel.SliceName+"::Action", actionTemplateModel.BaseActionClass)
+	}
+}
+
+func TestNewGenerator_MultipleSlicesNeedHanamiLayout(t *testing.T) {
+	_, err := NewGenerator("fixtures/test_spec_slices.yaml", "TestApp", "API")
+	assert.ErrorContains(t, err, "operations are routed into 2 slices (API, Internal), which needs the hanami layout")
+}
@@ -8,8 +8,11 @@ import (
 	"fmt"
 	"github.com/deepmap/oapi-codegen/pkg/codegen"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 )
 
 //go:embed templates/* templates/fragments/*
@@ -17,20 +20,36 @@ var templatesFS embed.FS
 var templatesFilePath = "templates"
 var routesTemplateFileName = "routes.rb.tmpl"
 var baseActionTemplateFileName = "base_action.rb.tmpl"
+var appActionTemplateFileName = "app_action.rb.tmpl"
 var actionTemplateFileName = "action.rb.tmpl"
 var serviceTemplateFileName = "service.rb.tmpl"
 var contractsTemplateFileName = "contracts.rb.tmpl"
 var schemasTemplateFileName = "schemas.rb.tmpl"
+var structsTemplateFileName = "structs.rb.tmpl"
+var serializersTemplateFileName = "serializers.rb.tmpl"
+var requestSpecTemplateFileName = "request_spec.rb.tmpl"
+var factoryTemplateFileName = "factory.rb.tmpl"
+var clientTemplateFileName = "client.rb.tmpl"
+var clientClassTemplateFileName = "client_class.rb.tmpl"
+var relationTemplateFileName = "relation.rb.tmpl"
+var repoTemplateFileName = "repo.rb.tmpl"
+var structTemplateFileName = "struct.rb.tmpl"
+var migrationTemplateFileName = "migration.rb.tmpl"
+var middlewareTemplateFileName = "middleware.rb.tmpl"
+
+// now is when migrations are timestamped with.
+var now = time.Now
 
 type Writer struct {
 	AppName   string
 	OutputDir string
+	Layout    Layout
 	Templates *template.Template
 }
 
-func NewWriter(outputDir string, appName string) (*Writer, error) {
-	trimmedOutputDir := strings.Trim(outputDir, "/")
-	templates, err := loadTemplates()
+func NewWriter(outputDir string, appName string, templatesDir string, layout Layout) (*Writer, error) {
+	trimmedOutputDir := filepath.Clean(outputDir)
+	templates, err := loadTemplates(templatesDir)
 	if err != nil {
 		return nil, err
 	}
@@ -38,240 +57,665 @@ func NewWriter(outputDir string, appName string) (*Writer, error) {
 	return &Writer{
 		AppName:   appName,
 		OutputDir: trimmedOutputDir,
+		Layout:    layout,
 		Templates: templates,
 	}, nil
 }
 
 var templateFunctions = merge(codegen.TemplateFunctions, template.FuncMap{
-	"toSnake": toSnake,
+	"toSnake":     toSnake,
+	"moduleKey":   moduleKey,
+	"rubyLiteral": rubyLiteral,
+	"structType":  structType,
+	"inModules":   inModules,
 })
 
-func loadTemplates() (*template.Template, error) {
-	return template.New("templates").Funcs(templateFunctions).ParseFS(
+// customFunctionsDirName is the directory inside a user's templates dir holding custom template functions.
+// Each functions/<name>.tmpl becomes a function called <name>, which renders the template with its argument
+// as dot (or the list of arguments, if there's more than one) and returns the output as a string.
+var customFunctionsDirName = "functions"
+
+var customFunctionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadTemplates parses the embedded templates, then overlays any templates found in templatesDir on top of them.
+// User templates replace embedded ones with the same file name (or the same {{define}} name, for fragments),
+// so overriding just action.rb.tmpl or the "attribute" fragment works.
+func loadTemplates(templatesDir string) (*template.Template, error) {
+	t := template.New("templates")
+
+	funcs := template.FuncMap{}
+	for name, fn := range templateFunctions {
+		funcs[name] = fn
+	}
+	// include renders a template, e.g. a {{define}}d one, to a string, so it can be piped into inModules
+	funcs["include"] = func(name string, data any) (string, error) {
+		buf, err := executeTemplate(t, name, data)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	var customFunctionFiles []string
+	if templatesDir != "" {
+		info, err := os.Stat(templatesDir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading templates dir: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("templates dir %s is not a directory", templatesDir)
+		}
+
+		customFunctionFiles, err = filepath.Glob(filepath.Join(templatesDir, customFunctionsDirName, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("error finding custom template functions: %w", err)
+		}
+
+		for _, filePath := range customFunctionFiles {
+			name := strings.TrimSuffix(filepath.Base(filePath), ".tmpl")
+			if !customFunctionNameRegex.MatchString(name) {
+				return nil, fmt.Errorf("custom template function %s must be a valid identifier", filePath)
+			}
+			funcs[name] = customTemplateFunction(t, customFunctionTemplateName(name))
+		}
+	}
+
+	t, err := t.Funcs(funcs).ParseFS(
 		templatesFS,
 		templatesFilePath+"/*.tmpl",
 		templatesFilePath+"/fragments/*.tmpl",
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if templatesDir == "" {
+		return t, nil
+	}
+
+	for _, filePath := range customFunctionFiles {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading custom template function %s: %w", filePath, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(filePath), ".tmpl")
+		_, err = t.New(customFunctionTemplateName(name)).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing custom template function %s: %w", filePath, err)
+		}
+	}
+
+	var overrideFiles []string
+	for _, pattern := range []string{"*.tmpl", "fragments/*.tmpl"} {
+		matches, err := filepath.Glob(filepath.Join(templatesDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("error finding templates in %s: %w", templatesDir, err)
+		}
+		overrideFiles = append(overrideFiles, matches...)
+	}
+
+	if len(overrideFiles) == 0 {
+		return t, nil
+	}
+
+	t, err = t.ParseFiles(overrideFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing templates in %s: %w", templatesDir, err)
+	}
+
+	return t, nil
+}
+
+func customFunctionTemplateName(name string) string {
+	return customFunctionsDirName + "/" + name
+}
+
+func customTemplateFunction(t *template.Template, templateName string) func(args ...any) (string, error) {
+	return func(args ...any) (string, error) {
+		var data any = args
+		if len(args) == 1 {
+			data = args[0]
+		}
+
+		buf, err := executeTemplate(t, templateName, data)
+		if err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	}
 }
 
 func (w Writer) WriteFilesFromTemplateModels(templateModels *TemplateModels) error {
-	err := w.WriteRoutesFileFromModel(templateModels.RoutesFileTemplateModel)
+	files, err := w.RenderFilesFromTemplateModels(templateModels)
 	if err != nil {
-		return fmt.Errorf("failed to write routes file: %w\n", err)
+		return err
+	}
+
+	return commitFiles(files)
+}
 
+// RenderFilesFromTemplateModels executes every template in memory, without touching the output directory.
+// Nothing gets written until every template has rendered successfully.
+func (w Writer) RenderFilesFromTemplateModels(templateModels *TemplateModels) ([]renderedFile, error) {
+	var files []renderedFile
+	artifacts := templateModels.Artifacts
+
+	if artifacts.Includes(ArtifactRoutes) {
+		routesFile, err := w.RenderRoutesFileFromModel(templateModels.RoutesFileTemplateModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render routes file: %w\n", err)
+		}
+		files = append(files, routesFile)
 	}
 
-	err = w.WriteBaseActionFile()
-	if err != nil {
-		return fmt.Errorf("failed to write base action file: %w\n", err)
+	if artifacts.Includes(ArtifactBaseAction) {
+		baseActionFiles, err := w.RenderBaseActionFilesFromModels(templateModels.BaseActionTemplateModels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render base action file: %w\n", err)
+		}
+		files = append(files, baseActionFiles...)
 	}
 
-	err = w.WriteActionFilesFromModels(templateModels.ActionTemplateModels)
-	if err != nil {
-		return fmt.Errorf("failed to write action files: %w\n", err)
+	if artifacts.Includes(ArtifactActions) {
+		actionFiles, err := w.RenderActionFilesFromModels(templateModels.ActionTemplateModels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render action files: %w\n", err)
+		}
+		files = append(files, actionFiles...)
 	}
 
-	err = w.WriteServiceFilesFromModels(templateModels.ServiceTemplateModels)
-	if err != nil {
-		return fmt.Errorf("failed to write service files: %w\n", err)
+	if artifacts.Includes(ArtifactServices) || artifacts.Includes(ArtifactMock) {
+		serviceFiles, err := w.RenderServiceFilesFromModels(templateModels.ServiceTemplateModels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render service files: %w\n", err)
+		}
+		files = append(files, serviceFiles...)
 	}
 
-	err = w.WriteContractsFileFromModel(templateModels.ContractsFileTemplateModel)
-	if err != nil {
-		return fmt.Errorf("failed to write contracts file: %w\n", err)
+	if artifacts.Includes(ArtifactContracts) {
+		for _, model := range templateModels.ContractsFileTemplateModels {
+			contractsFile, err := w.RenderContractsFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render contracts file: %w\n", err)
+			}
+			files = append(files, contractsFile)
+		}
 	}
 
-	err = w.WriteSchemasFileFromModel(templateModels.SchemasFileTemplateModel)
-	if err != nil {
-		return fmt.Errorf("failed to write schemas file: %w\n", err)
+	if artifacts.Includes(ArtifactSchemas) {
+		for _, model := range templateModels.SchemasFileTemplateModels {
+			schemasFile, err := w.RenderSchemasFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render schemas file: %w\n", err)
+			}
+			files = append(files, schemasFile)
+		}
+	}
+
+	if artifacts.Includes(ArtifactStructs) {
+		for _, model := range templateModels.StructsFileTemplateModels {
+			structsFile, err := w.RenderStructsFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render structs file: %w\n", err)
+			}
+			files = append(files, structsFile)
+		}
+	}
+
+	if artifacts.Includes(ArtifactSerializers) {
+		for _, model := range templateModels.SerializersFileTemplateModels {
+			serializersFile, err := w.RenderSerializersFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render serializers file: %w\n", err)
+			}
+			files = append(files, serializersFile)
+		}
+	}
+
+	if artifacts.Includes(ArtifactRequestSpecs) {
+		requestSpecFiles, err := w.RenderRequestSpecFilesFromModels(templateModels.RequestSpecTemplateModels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render request spec files: %w\n", err)
+		}
+		files = append(files, requestSpecFiles...)
+	}
+
+	if artifacts.Includes(ArtifactFactories) {
+		for _, model := range templateModels.FactoryTemplateModels {
+			factoryFile, err := w.RenderFactoryFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render factory file: %w\n", err)
+			}
+			files = append(files, factoryFile)
+		}
+	}
+
+	if artifacts.Includes(ArtifactClient) {
+		for _, model := range templateModels.ClientTemplateModels {
+			clientFiles, err := w.RenderClientFilesFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render client files: %w\n", err)
+			}
+			files = append(files, clientFiles...)
+		}
+	}
+
+	if artifacts.Includes(ArtifactPersistence) {
+		persistenceFiles, err := w.RenderPersistenceFilesFromModels(templateModels.PersistenceTemplateModels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render persistence files: %w\n", err)
+		}
+		files = append(files, persistenceFiles...)
 	}
 
-	return nil
+	if artifacts.Includes(ArtifactMiddleware) {
+		for _, model := range templateModels.MiddlewareTemplateModels {
+			middlewareFile, err := w.RenderMiddlewareFileFromModel(model)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render middleware file: %w\n", err)
+			}
+			files = append(files, middlewareFile)
+		}
+	}
+
+	return files, nil
 }
 
-func (w Writer) WriteRoutesFileFromModel(model RoutesFileTemplateModel) error {
+func (w Writer) RenderRoutesFileFromModel(model RoutesFileTemplateModel) (renderedFile, error) {
 	buf, err := w.ExecuteRoutesFileTemplate(model)
 	if err != nil {
-		return fmt.Errorf("error executing routes file template: %w", err)
+		return renderedFile{}, fmt.Errorf("error executing routes file template: %w", err)
 	}
 
-	return w.WriteRoutesFile(buf)
+	return newRenderedFile(w.RoutesFilePath(), buf), nil
 }
 
 func (w Writer) ExecuteRoutesFileTemplate(model RoutesFileTemplateModel) (*bytes.Buffer, error) {
 	return executeTemplate(w.Templates, routesTemplateFileName, model)
 }
 
-func (w Writer) WriteRoutesFile(data *bytes.Buffer) error {
-	err := w.createConfigDirIfNotExists()
-	if err != nil {
-		return err
+func (w Writer) RoutesFilePath() string {
+	return w.OutputDir + "/config/routes.rb"
+}
+
+func (w Writer) RenderBaseActionFilesFromModels(models []BaseActionTemplateModel) ([]renderedFile, error) {
+	var files []renderedFile
+	for _, model := range models {
+		data, err := executeTemplate(w.Templates, baseActionTemplateFileName, model)
+		if err != nil {
+			return nil, fmt.Errorf("could not execute %s: %w\n", baseActionTemplateFileName, err)
+		}
+		files = append(files, newRenderedFile(w.BaseActionFilePath(model), data))
 	}
-	return writeFile(w.OutputDir+"/config/routes.rb", data)
+
+	// the slices' base actions inherit from the app's, which `hanami new` creates. It's the app's file though,
+	// so we only fill it in if it's missing, and never overwrite it.
+	if w.Layout == LayoutHanami && len(models) > 0 && !doesFileExist(w.AppActionFilePath()) {
+		data, err := executeTemplate(w.Templates, appActionTemplateFileName, models[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not execute %s: %w\n", appActionTemplateFileName, err)
+		}
+		files = append(files, newRenderedFile(w.AppActionFilePath(), data))
+	}
+
+	return files, nil
 }
 
-func (w Writer) WriteBaseActionFile() error {
-	data, err := executeTemplate(w.Templates, baseActionTemplateFileName, map[string]string{"AppName": w.AppName})
-	if err != nil {
-		return fmt.Errorf("could not execute action_base.rb.tmpl: %w\n", err)
+func (w Writer) BaseActionFilePath(model BaseActionTemplateModel) string {
+	if w.Layout == LayoutHanami {
+		return w.sliceDir(model.SliceName) + "/action.rb"
+	}
+
+	return w.OutputDir + "/base_action.rb"
+}
+
+func (w Writer) AppActionFilePath() string {
+	return w.OutputDir + "/app/action.rb"
+}
+
+// sliceDir is the directory a slice's actions and services go in. That's slices/<slice> in a Hanami app,
+// or just the output dir in the flat layout.
+func (w Writer) sliceDir(sliceName string) string {
+	if w.Layout == LayoutHanami {
+		return fmt.Sprintf("%s/slices/%s", w.OutputDir, toSnake(sliceName))
 	}
-	return writeFile(w.OutputDir+"/base_action.rb", data)
+
+	return w.OutputDir
 }
 
-func (w Writer) WriteActionFilesFromModels(actionTemplateModels []ActionTemplateModel) error {
+func (w Writer) RenderActionFilesFromModels(actionTemplateModels []ActionTemplateModel) ([]renderedFile, error) {
+	var files []renderedFile
 	for _, model := range actionTemplateModels {
 		actionFileBuf, err := w.ExecuteActionFileTemplate(model)
 		if err != nil {
-			return fmt.Errorf("error executing action file template: %w", err)
+			return nil, fmt.Errorf("error executing action file template: %w", err)
 		}
 
-		err = w.WriteActionFile(model, actionFileBuf)
-		if err != nil {
-			return fmt.Errorf("error writing action file: %w", err)
-		}
+		files = append(files, newRenderedFile(w.ActionFilePath(model), actionFileBuf))
 	}
 
-	return nil
+	return files, nil
 }
 
 func (w Writer) ExecuteActionFileTemplate(model ActionTemplateModel) (*bytes.Buffer, error) {
 	return executeTemplate(w.Templates, actionTemplateFileName, model)
 }
 
-func (w Writer) WriteActionFile(model ActionTemplateModel, data *bytes.Buffer) error {
-	actionDirectory := fmt.Sprintf("%s/actions/%s/", w.OutputDir, toSnake(model.ModuleName))
-	err := os.MkdirAll(actionDirectory, os.ModePerm)
-	if err != nil {
-		return fmt.Errorf("error creating action directory %s: %w", actionDirectory, err)
-	}
-
-	actionFilePath := fmt.Sprintf("%s%s.rb", actionDirectory, toSnake(model.ActionName))
-	err = writeFile(actionFilePath, data)
-	if err != nil {
-		return fmt.Errorf("error writing action file %s: %w", actionFilePath, err)
-	}
-
-	return nil
+func (w Writer) ActionFilePath(model ActionTemplateModel) string {
+	return fmt.Sprintf("%s/actions/%s/%s.rb", w.sliceDir(model.SliceName), moduleDir(model.ModuleName), toSnake(model.ActionName))
 }
 
-func (w Writer) WriteServiceFilesFromModels(models []ServiceTemplateModel) error {
+func (w Writer) RenderServiceFilesFromModels(models []ServiceTemplateModel) ([]renderedFile, error) {
+	var files []renderedFile
 	for _, model := range models {
-		buf, err := w.ExecuteServiceFileTemplate(model)
-		if err != nil {
-			return fmt.Errorf("error executing service file template: %w", err)
+		serviceFilePath := w.ServiceFilePath(model)
+		if doesFileExist(serviceFilePath) {
+			// don't write the thing, we don't want to overwrite service files if they already exist, unless it's a
+			// mock taking the place of the empty service generated before, which nobody has filled in
+			if model.MockResponse == "" {
+				continue
+			}
+			isStub, err := w.isServiceStub(serviceFilePath, model)
+			if err != nil {
+				return nil, err
+			}
+			if !isStub {
+				continue
+			}
 		}
 
-		err = w.WriteServiceFile(model, buf)
+		buf, err := w.ExecuteServiceFileTemplate(model)
 		if err != nil {
-			return fmt.Errorf("error writing service file: %w", err)
+			return nil, fmt.Errorf("error executing service file template: %w", err)
 		}
-	}
 
-	return nil
-}
+		files = append(files, newRenderedFile(serviceFilePath, buf))
+	}
 
-func (w Writer) ExecuteServiceFileTemplate(model ServiceTemplateModel) (*bytes.Buffer, error) {
-	return executeTemplate(w.Templates, serviceTemplateFileName, model)
+	return files, nil
 }
 
-func (w Writer) WriteServiceFile(model ServiceTemplateModel, data *bytes.Buffer) error {
-	parentDir := fmt.Sprintf("%s/services/%s/", w.OutputDir, toSnake(model.ModuleName))
-	err := os.MkdirAll(parentDir, os.ModePerm)
+// isServiceStub is whether the service file at serviceFilePath is still exactly the empty service generated for
+// model, rather than one that's been filled in.
+func (w Writer) isServiceStub(serviceFilePath string, model ServiceTemplateModel) (bool, error) {
+	model.MockResponse = ""
+	stub, err := w.ExecuteServiceFileTemplate(model)
 	if err != nil {
-		return fmt.Errorf("error creating parent directory %s: %w", parentDir, err)
+		return false, fmt.Errorf("error executing service file template: %w", err)
 	}
 
-	serviceFilePath := fmt.Sprintf("%s%s.rb", parentDir, toSnake(model.ServiceName))
-
-	fileExists := doesFileExist(serviceFilePath)
-	if fileExists {
-		// don't write the thing, we don't want to overwrite service files if they already exist
-		return nil
-	}
-
-	err = writeFile(serviceFilePath, data)
+	existing, err := os.ReadFile(serviceFilePath)
 	if err != nil {
-		return fmt.Errorf("error writing service file %s: %w", serviceFilePath, err)
+		return false, fmt.Errorf("error reading service file: %w", err)
 	}
 
-	return nil
+	return bytes.Equal(existing, stub.Bytes()), nil
+}
+
+func (w Writer) ExecuteServiceFileTemplate(model ServiceTemplateModel) (*bytes.Buffer, error) {
+	return executeTemplate(w.Templates, serviceTemplateFileName, model)
+}
+
+func (w Writer) ServiceFilePath(model ServiceTemplateModel) string {
+	return fmt.Sprintf("%s/services/%s/%s.rb", w.sliceDir(model.SliceName), moduleDir(model.ModuleName), toSnake(model.ServiceName))
 }
 
-func (w Writer) WriteContractsFileFromModel(model ContractsFileTemplateModel) error {
+func (w Writer) RenderContractsFileFromModel(model ContractsFileTemplateModel) (renderedFile, error) {
 	buf, err := w.ExecuteContractsFileTemplate(model)
 	if err != nil {
-		return fmt.Errorf("error executing contracts file template: %w", err)
+		return renderedFile{}, fmt.Errorf("error executing contracts file template: %w", err)
 	}
 
-	return w.WriteContractsFile(buf)
+	return newRenderedFile(w.ContractsFilePath(model), buf), nil
 }
 
 func (w Writer) ExecuteContractsFileTemplate(model ContractsFileTemplateModel) (*bytes.Buffer, error) {
 	return executeTemplate(w.Templates, contractsTemplateFileName, model)
 }
 
-func (w Writer) WriteContractsFile(data *bytes.Buffer) error {
-	err := w.createActionsDirIfNotExists()
-	if err != nil {
-		return err
-	}
-
-	return writeFile(w.OutputDir+"/actions/contracts.rb", data)
+func (w Writer) ContractsFilePath(model ContractsFileTemplateModel) string {
+	return w.sliceDir(model.SliceName) + "/actions/contracts.rb"
 }
 
-func (w Writer) WriteSchemasFileFromModel(model SchemasFileTemplateModel) error {
+func (w Writer) RenderSchemasFileFromModel(model SchemasFileTemplateModel) (renderedFile, error) {
 	buf, err := w.ExecuteSchemasFileTemplate(model)
 	if err != nil {
-		return fmt.Errorf("error executing schemas file template: %w", err)
+		return renderedFile{}, fmt.Errorf("error executing schemas file template: %w", err)
 	}
 
-	return w.WriteSchemasFile(buf)
+	return newRenderedFile(w.SchemasFilePath(model), buf), nil
 }
 
 func (w Writer) ExecuteSchemasFileTemplate(model SchemasFileTemplateModel) (*bytes.Buffer, error) {
 	return executeTemplate(w.Templates, schemasTemplateFileName, model)
 }
 
-func (w Writer) WriteSchemasFile(data *bytes.Buffer) error {
-	err := w.createActionsDirIfNotExists()
+func (w Writer) SchemasFilePath(model SchemasFileTemplateModel) string {
+	return w.sliceDir(model.SliceName) + "/actions/schemas.rb"
+}
+
+func (w Writer) RenderStructsFileFromModel(model StructsFileTemplateModel) (renderedFile, error) {
+	buf, err := w.ExecuteStructsFileTemplate(model)
 	if err != nil {
-		return err
+		return renderedFile{}, fmt.Errorf("error executing structs file template: %w", err)
 	}
 
-	return writeFile(w.OutputDir+"/actions/schemas.rb", data)
+	return newRenderedFile(w.StructsFilePath(model), buf), nil
+}
+
+func (w Writer) ExecuteStructsFileTemplate(model StructsFileTemplateModel) (*bytes.Buffer, error) {
+	return executeTemplate(w.Templates, structsTemplateFileName, model)
+}
+
+func (w Writer) StructsFilePath(model StructsFileTemplateModel) string {
+	return w.sliceDir(model.SliceName) + "/actions/structs.rb"
 }
 
-func (w Writer) createConfigDirIfNotExists() error {
-	err := os.MkdirAll(w.OutputDir+"/config/", os.ModePerm)
+func (w Writer) RenderSerializersFileFromModel(model SerializersFileTemplateModel) (renderedFile, error) {
+	buf, err := w.ExecuteSerializersFileTemplate(model)
 	if err != nil {
-		return fmt.Errorf("error creating directory %s/config: %w", w.OutputDir, err)
+		return renderedFile{}, fmt.Errorf("error executing serializers file template: %w", err)
+	}
+
+	return newRenderedFile(w.SerializersFilePath(model), buf), nil
+}
+
+func (w Writer) ExecuteSerializersFileTemplate(model SerializersFileTemplateModel) (*bytes.Buffer, error) {
+	return executeTemplate(w.Templates, serializersTemplateFileName, model)
+}
+
+func (w Writer) SerializersFilePath(model SerializersFileTemplateModel) string {
+	return w.sliceDir(model.SliceName) + "/actions/serializers.rb"
+}
+
+func (w Writer) RenderRequestSpecFilesFromModels(models []RequestSpecTemplateModel) ([]renderedFile, error) {
+	var files []renderedFile
+	for _, model := range models {
+		requestSpecFilePath := w.RequestSpecFilePath(model)
+		if doesFileExist(requestSpecFilePath) {
+			// specs get filled in with setup, like services do with logic, so they're only written once too
+			continue
+		}
+
+		buf, err := executeTemplate(w.Templates, requestSpecTemplateFileName, model)
+		if err != nil {
+			return nil, fmt.Errorf("error executing request spec template: %w", err)
+		}
+
+		files = append(files, newRenderedFile(requestSpecFilePath, buf))
 	}
-	return nil
+
+	return files, nil
 }
 
-func (w Writer) createActionsDirIfNotExists() error {
-	err := os.MkdirAll(w.OutputDir+"/actions/", os.ModePerm)
+// RequestSpecFilePath is spec/requests/<module>/<action>_spec.rb, under spec/slices/<slice> in the hanami layout,
+// which is where Hanami keeps a slice's specs.
+func (w Writer) RequestSpecFilePath(model RequestSpecTemplateModel) string {
+	specDir := w.OutputDir + "/spec"
+	if w.Layout == LayoutHanami {
+		specDir = fmt.Sprintf("%s/spec/slices/%s", w.OutputDir, toSnake(model.SliceName))
+	}
+
+	return fmt.Sprintf("%s/requests/%s/%s_spec.rb", specDir, moduleDir(model.ModuleName), toSnake(model.ActionName))
+}
+
+func (w Writer) RenderFactoryFileFromModel(model FactoryTemplateModel) (renderedFile, error) {
+	buf, err := executeTemplate(w.Templates, factoryTemplateFileName, model)
 	if err != nil {
-		return fmt.Errorf("error creating directory %s/actions: %w", w.OutputDir, err)
+		return renderedFile{}, fmt.Errorf("error executing factory template: %w", err)
 	}
-	return nil
+
+	return newRenderedFile(w.FactoryFilePath(model), buf), nil
 }
 
-func (w Writer) createServicesDirIfNotExists() error {
-	err := os.MkdirAll(w.OutputDir+"/services/", os.ModePerm)
+// FactoryFilePath is spec/support/factories/<schema>.rb in either layout, since schemas are shared by every slice.
+func (w Writer) FactoryFilePath(model FactoryTemplateModel) string {
+	return fmt.Sprintf("%s/spec/support/factories/%s.rb", w.OutputDir, toSnake(model.SchemaName))
+}
+
+func (w Writer) RenderClientFilesFromModel(model ClientTemplateModel) ([]renderedFile, error) {
+	clientFilePath := w.ClientFilePath(model)
+	for _, filePath := range []string{
+		w.SchemasFilePath(SchemasFileTemplateModel{SliceName: model.SliceName}),
+		w.ContractsFilePath(ContractsFileTemplateModel{SliceName: model.SliceName}),
+	} {
+		relativePath, err := filepath.Rel(filepath.Dir(clientFilePath), filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error finding %s from the client: %w", filePath, err)
+		}
+		model.Requires = append(model.Requires, strings.TrimSuffix(filepath.ToSlash(relativePath), ".rb"))
+	}
+
+	buf, err := executeTemplate(w.Templates, clientTemplateFileName, model)
 	if err != nil {
-		return fmt.Errorf("error creating directory %s/services: %w", w.OutputDir, err)
+		return nil, fmt.Errorf("error executing client template: %w", err)
+	}
+	files := []renderedFile{newRenderedFile(clientFilePath, buf)}
+
+	for _, class := range model.Classes {
+		buf, err := executeTemplate(w.Templates, clientClassTemplateFileName, class)
+		if err != nil {
+			return nil, fmt.Errorf("error executing client class template: %w", err)
+		}
+		files = append(files, newRenderedFile(w.ClientClassFilePath(class), buf))
+	}
+
+	return files, nil
+}
+
+// ClientFilePath is client/<slice>.rb, which loads the rest of the client, in either layout. The client's meant to
+// be copied into other apps, so it's kept out of the slices, where Hanami would autoload it.
+func (w Writer) ClientFilePath(model ClientTemplateModel) string {
+	return fmt.Sprintf("%s/client/%s.rb", w.OutputDir, toSnake(model.SliceName))
+}
+
+func (w Writer) ClientClassFilePath(model ClientClassTemplateModel) string {
+	return fmt.Sprintf("%s/client/%s/%s.rb", w.OutputDir, toSnake(model.SliceName), toSnake(model.ClassName))
+}
+
+// RenderPersistenceFilesFromModels renders the relation, repository and entity struct for each persisted schema,
+// and its migration draft if there is one. They're all starting points to fill in, so like services they're only
+// written if they don't exist yet. Migrations are timestamped a second apart, so they run in the models' order.
+func (w Writer) RenderPersistenceFilesFromModels(models []PersistenceTemplateModel) ([]renderedFile, error) {
+	var files []renderedFile
+	timestamp := now().UTC()
+	for _, model := range models {
+		filePaths := map[string]string{
+			relationTemplateFileName: w.RelationFilePath(model),
+			repoTemplateFileName:     w.RepoFilePath(model),
+			structTemplateFileName:   w.StructFilePath(model),
+		}
+		if model.Migration && !w.hasMigration(model) {
+			filePaths[migrationTemplateFileName] = w.MigrationFilePath(model, timestamp)
+			timestamp = timestamp.Add(time.Second)
+		}
+
+		for _, templateFileName := range sortedKeys(filePaths) {
+			filePath := filePaths[templateFileName]
+			if doesFileExist(filePath) {
+				continue
+			}
+
+			buf, err := executeTemplate(w.Templates, templateFileName, model)
+			if err != nil {
+				return nil, fmt.Errorf("could not execute %s: %w\n", templateFileName, err)
+			}
+			files = append(files, newRenderedFile(filePath, buf))
+		}
+	}
+
+	return files, nil
+}
+
+// persistenceDir is where the persistence classes go: app/ in a Hanami app, since schemas are shared by every
+// slice, or the output dir in the flat layout.
+func (w Writer) persistenceDir() string {
+	if w.Layout == LayoutHanami {
+		return w.OutputDir + "/app"
 	}
-	return nil
+
+	return w.OutputDir
+}
+
+func (w Writer) RelationFilePath(model PersistenceTemplateModel) string {
+	return fmt.Sprintf("%s/relations/%s.rb", w.persistenceDir(), model.TableName)
+}
+
+func (w Writer) RepoFilePath(model PersistenceTemplateModel) string {
+	return fmt.Sprintf("%s/repos/%s_repo.rb", w.persistenceDir(), toSnake(model.SchemaName))
+}
+
+func (w Writer) StructFilePath(model PersistenceTemplateModel) string {
+	return fmt.Sprintf("%s/structs/%s.rb", w.persistenceDir(), toSnake(model.SchemaName))
 }
 
-func writeFile(filePath string, data *bytes.Buffer) error {
-	err := os.WriteFile(filePath, data.Bytes(), 0644)
+// migrationsDir is config/db/migrate in a Hanami app, or db/migrate in the flat layout.
+func (w Writer) migrationsDir() string {
+	if w.Layout == LayoutHanami {
+		return w.OutputDir + "/config/db/migrate"
+	}
+
+	return w.OutputDir + "/db/migrate"
+}
+
+func (w Writer) MigrationFilePath(model PersistenceTemplateModel, timestamp time.Time) string {
+	return fmt.Sprintf("%s/%s_create_%s.rb", w.migrationsDir(), timestamp.Format("20060102150405"), model.TableName)
+}
+
+func (w Writer) RenderMiddlewareFileFromModel(model MiddlewareTemplateModel) (renderedFile, error) {
+	middlewareFilePath := w.MiddlewareFilePath(model)
+	if w.Layout == LayoutFlat {
+		for _, filePath := range []string{
+			w.SchemasFilePath(SchemasFileTemplateModel{SliceName: model.SliceName}),
+			w.ContractsFilePath(ContractsFileTemplateModel{SliceName: model.SliceName}),
+		} {
+			relativePath, err := filepath.Rel(filepath.Dir(middlewareFilePath), filePath)
+			if err != nil {
+				return renderedFile{}, fmt.Errorf("error finding %s from the middleware: %w", filePath, err)
+			}
+			model.Requires = append(model.Requires, strings.TrimSuffix(filepath.ToSlash(relativePath), ".rb"))
+		}
+	}
+
+	buf, err := executeTemplate(w.Templates, middlewareTemplateFileName, model)
 	if err != nil {
-		return fmt.Errorf("error writing to file %s: %w", filePath, err)
+		return renderedFile{}, fmt.Errorf("error executing middleware template: %w", err)
 	}
 
-	return nil
+	return newRenderedFile(middlewareFilePath, buf), nil
+}
+
+// MiddlewareFilePath is <slice>/middleware/validation.rb, which Hanami autoloads as <Slice>::Middleware::Validation.
+func (w Writer) MiddlewareFilePath(model MiddlewareTemplateModel) string {
+	return fmt.Sprintf("%s/middleware/validation.rb", w.sliceDir(model.SliceName))
+}
+
+// hasMigration reports whether a migration creating the model's table has already been drafted, whenever that was.
+func (w Writer) hasMigration(model PersistenceTemplateModel) bool {
+	matches, _ := filepath.Glob(fmt.Sprintf("%s/*_create_%s.rb", w.migrationsDir(), model.TableName))
+	return len(matches) > 0
 }
 
 func doesFileExist(filePath string) bool {
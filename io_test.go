@@ -0,0 +1,154 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplates_OverridesByName(t *testing.T) {
+	templatesDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(templatesDir, "fragments"), os.ModePerm))
+	assert.NoError(t, os.MkdirAll(filepath.Join(templatesDir, "functions"), os.ModePerm))
+
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(templatesDir, "action.rb.tmpl"),
+		[]byte(`{{logLine .ActionName}} in {{.ModuleName}}`),
+		0644,
+	))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(templatesDir, "fragments", "attribute.rb.tmpl"),
+		[]byte(`{{define "attribute"}}attr {{.AttributeName}}{{end}}`),
+		0644,
+	))
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(templatesDir, "functions", "logLine.tmpl"),
+		[]byte(`logger.info("{{. | toSnake}}")`),
+		0644,
+	))
+
+	w, err := NewWriter("gen", "TestApp", templatesDir, LayoutFlat)
+	assert.NoError(t, err)
+
+	action, err := w.ExecuteActionFileTemplate(ActionTemplateModel{ActionName: "GetBooks", ModuleName: "books"})
+	assert.NoError(t, err)
+	assert.Equal(t, `logger.info("get_books") in books`, action.String())
+
+	schemas, err := w.ExecuteSchemasFileTemplate(SchemasFileTemplateModel{
+		SliceName: "API",
+		Schemas: []SchemaTemplateModel{
+			{SchemaName: "Book", Attributes: []AttributeDefinition{{AttributeName: "title"}}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, schemas.String(), "attr title")
+
+	// templates that weren't overridden still come from the embedded set
+	service, err := w.ExecuteServiceFileTemplate(ServiceTemplateModel{SliceName: "API", ServiceName: "GetBooks", ModuleName: "books"})
+	assert.NoError(t, err)
+	assert.Contains(t, service.String(), "class GetBooks")
+}
+
+func TestLoadTemplates_MissingTemplatesDir(t *testing.T) {
+	_, err := NewWriter("gen", "TestApp", filepath.Join(t.TempDir(), "missing"), LayoutFlat)
+	assert.ErrorContains(t, err, "error reading templates dir")
+}
+
+func TestLoadTemplates_InvalidCustomFunctionName(t *testing.T) {
+	templatesDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(templatesDir, "functions"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(templatesDir, "functions", "log-line.tmpl"), []byte(""), 0644))
+
+	_, err := NewWriter("gen", "TestApp", templatesDir, LayoutFlat)
+	assert.ErrorContains(t, err, "must be a valid identifier")
+}
+
+// generateInto generates from config in the given layout, writing to config.OutputDir, or a temporary directory if
+// it's empty, and returns the directory written to.
+func generateInto(t *testing.T, config *Config, layout Layout) string {
+	t.Helper()
+	config.Layout = layout
+	outputDir := config.OutputDir
+	if outputDir == "" {
+		outputDir = t.TempDir()
+	}
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	templateModels, err := g.GenerateTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating template models: %s\n", err)
+	}
+
+	w, err := NewWriter(outputDir, config.AppName, config.TemplatesDir, layout)
+	if err != nil {
+		t.Fatalf("error creating writer: %s\n", err)
+	}
+
+	err = w.WriteFilesFromTemplateModels(templateModels)
+	if err != nil {
+		t.Fatalf("error writing files: %s\n", err)
+	}
+	return outputDir
+}
+
+func TestWriter_HanamiLayout(t *testing.T) {
+	outputDir := generateInto(t, &Config{
+		Input:     "fixtures/test_spec.yaml",
+		AppName:   "Bookshop",
+		SliceName: "Catalogue",
+	}, LayoutHanami)
+
+	for _, filePath := range []string{
+		"config/routes.rb",
+		"app/action.rb",
+		"slices/catalogue/action.rb",
+		"slices/catalogue/actions/contracts.rb",
+		"slices/catalogue/actions/schemas.rb",
+		"slices/catalogue/actions/structs.rb",
+		"slices/catalogue/actions/books/get_books.rb",
+		"slices/catalogue/services/books/get_books.rb",
+		"spec/slices/catalogue/requests/books/get_books_spec.rb",
+	} {
+		assert.FileExists(t, filepath.Join(outputDir, filePath))
+	}
+	assert.NoFileExists(t, filepath.Join(outputDir, "base_action.rb"))
+	assert.NoFileExists(t, filepath.Join(outputDir, "slices/catalogue/middleware/validation.rb"))
+
+	sliceAction, err := os.ReadFile(filepath.Join(outputDir, "slices/catalogue/action.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(sliceAction), "module Catalogue\n  class Action < Bookshop::Action")
+
+	action, err := os.ReadFile(filepath.Join(outputDir, "slices/catalogue/actions/books/get_books.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(action), "class GetBooks < Catalogue::Action")
+}
+
+func TestWriter_HanamiLayout_KeepsExistingAppAction(t *testing.T) {
+	outputDir := t.TempDir()
+	appActionPath := filepath.Join(outputDir, "app", "action.rb")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(appActionPath), os.ModePerm))
+	assert.NoError(t, os.WriteFile(appActionPath, []byte("# ours"), 0644))
+
+	w, err := NewWriter(outputDir, "Bookshop", "", LayoutHanami)
+	if err != nil {
+		t.Fatalf("error creating writer: %s\n", err)
+	}
+
+	files, err := w.RenderBaseActionFilesFromModels([]BaseActionTemplateModel{
+		{
+			AppName:     "Bookshop",
+			SliceName:   "Catalogue",
+			ModuleName:  "Catalogue",
+			ClassName:   "Action",
+			ParentClass: "Bookshop::Action",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, filepath.Join(outputDir, "slices/catalogue/action.rb"), files[0].Path)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"github.com/deepmap/oapi-codegen/pkg/codegen"
+	"github.com/getkin/kin-openapi/openapi3"
+	"strings"
+)
+
+// KeyTransform decides what services call the properties and params they're given, from their names in the spec.
+// Contracts rename keys as they validate them, see KeyMapTemplateModel, and serializers rename them back.
+type KeyTransform string
+
+const (
+	// KeyTransformSnake snake cases them, e.g. firstName becomes first_name, as is usual in Ruby.
+	KeyTransformSnake KeyTransform = "snake"
+	// KeyTransformCamel camel cases them, e.g. first_name becomes firstName.
+	KeyTransformCamel KeyTransform = "camel"
+	// KeyTransformNone leaves them as they are in the spec.
+	KeyTransformNone KeyTransform = "none"
+)
+
+// serviceKey is what services call a property or param, going by g.KeyTransform.
+func (g Generator) serviceKey(name string) string {
+	switch g.KeyTransform {
+	case KeyTransformCamel:
+		return codegen.LowercaseFirstCharacter(codegen.ToCamelCase(name))
+	case KeyTransformNone:
+		return name
+	default:
+		return toSnake(name)
+	}
+}
+
+// KeyMapTemplateModel is a component schema's KeyMap in contracts.rb, which contracts whose properties refer to the
+// schema use for them.
+type KeyMapTemplateModel struct {
+	SchemaName string
+	Keys       []KeyTemplateModel
+}
+
+// KeyTemplateModel renames a key from its name in the spec to what services call it, e.g. "firstName" to
+// :first_name. Only keys that are renamed, or have keys nested in them that are, are in a KeyMap.
+type KeyTemplateModel struct {
+	Name string
+	Key  string
+	// Nested is a Ruby expression for the KeyMap of the component schema the key's value, or its items, refer to,
+	// e.g. Keys::Owner, and NestedKeys the keys of an inline object.
+	Nested     string
+	NestedKeys []KeyTemplateModel
+}
+
+// GenerateKeyMapTemplateModels generates the KeyMaps of the component schemas that have keys to rename, in
+// schemaDefinitionOrder.
+func (g Generator) GenerateKeyMapTemplateModels() []KeyMapTemplateModel {
+	renamed := g.schemasWithRenamedKeys()
+
+	var keyMapTemplateModels []KeyMapTemplateModel
+	defined := map[string]bool{}
+	for _, schemaName := range schemaDefinitionOrder(g.Schemas) {
+		schemaExtensions, _ := readSchemaExtensions(g.Schemas[schemaName].Value.Extensions)
+		if !renamed[schemaName] || schemaExtensions.Skip {
+			continue
+		}
+		keyMapTemplateModels = append(keyMapTemplateModels, KeyMapTemplateModel{
+			SchemaName: schemaName,
+			Keys:       g.keyMapKeys(g.Schemas[schemaName], renamed, defined),
+		})
+		defined[schemaName] = true
+	}
+
+	return keyMapTemplateModels
+}
+
+// requestKeys are the keys of an operation's request contract to rename: its body's properties, and its params.
+func (g Generator) requestKeys(operationDefinition OperationDefinition) []KeyTemplateModel {
+	renamed := g.schemasWithRenamedKeys()
+
+	var keys []KeyTemplateModel
+	if operationDefinition.Spec.RequestBody != nil {
+		keys = g.keyMapKeys(operationDefinition.RequestBodySchema, renamed, nil)
+	}
+	for _, param := range operationDefinition.Spec.Parameters {
+		if extensions, _ := readSchemaExtensions(param.Value.Extensions); extensions.Skip {
+			continue
+		}
+		if key := g.serviceKey(param.Value.Name); key != param.Value.Name {
+			keys = append(keys, KeyTemplateModel{Name: param.Value.Name, Key: key})
+		}
+	}
+
+	return keys
+}
+
+// schemasWithRenamedKeys are the component schemas with a key to rename, in themselves or in a schema they refer
+// to, and so a KeyMap.
+func (g Generator) schemasWithRenamedKeys() map[string]bool {
+	var own []string
+	for _, schemaName := range sortedKeys(g.Schemas) {
+		if len(g.keyMapKeys(g.Schemas[schemaName], map[string]bool{}, nil)) > 0 {
+			own = append(own, schemaName)
+		}
+	}
+
+	renamed := map[string]bool{}
+	for _, schemaName := range sortedKeys(g.Schemas) {
+		for _, target := range own {
+			if g.schemaRefersTo(schemaName, target) {
+				renamed[schemaName] = true
+				break
+			}
+		}
+	}
+
+	return renamed
+}
+
+// keyMapKeys are the keys of an object schema to rename. References to the KeyMaps of component schemas that
+// aren't in defined are looked up lazily, as they come of cycles; a nil defined means they're all defined.
+func (g Generator) keyMapKeys(schemaRef *openapi3.SchemaRef, renamed map[string]bool, defined map[string]bool) []KeyTemplateModel {
+	if schemaRef == nil {
+		return nil
+	}
+
+	var keys []KeyTemplateModel
+	for _, propertyName := range sortedSchemaRefPropertyKeys(schemaRef) {
+		propertyRef := schemaRef.Value.Properties[propertyName]
+		attributeDefinition, ok := g.generateAttributeDefinition(propertyName, propertyRef, false)
+		if !ok {
+			continue
+		}
+
+		key := KeyTemplateModel{Name: propertyName, Key: attributeDefinition.Key}
+		if propertyRef.Value.Type == "array" && propertyRef.Value.Items != nil {
+			propertyRef = propertyRef.Value.Items
+		}
+		if schemaName, ok := strings.CutPrefix(propertyRef.Ref, componentSchemasRefPrefix); ok {
+			if renamed[schemaName] {
+				key.Nested = "Keys::" + schemaName
+				if defined != nil && !defined[schemaName] {
+					key.Nested = "-> { " + key.Nested + " }"
+				}
+			}
+		} else if len(propertyRef.Value.Properties) > 0 {
+			key.NestedKeys = g.keyMapKeys(propertyRef, renamed, defined)
+		}
+
+		if key.Name != key.Key || key.Nested != "" || len(key.NestedKeys) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
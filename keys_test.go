@@ -0,0 +1,130 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_serviceKey(t *testing.T) {
+	tests := []struct {
+		keyTransform KeyTransform
+		name         string
+		want         string
+	}{
+		{KeyTransformSnake, "firstName", "first_name"},
+		{KeyTransformSnake, "first_name", "first_name"},
+		{KeyTransformCamel, "first_name", "firstName"},
+		{KeyTransformCamel, "firstName", "firstName"},
+		{KeyTransformNone, "firstName", "firstName"},
+		{"", "profileImageUrl", "profile_image_url"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.keyTransform)+" "+tt.name, func(t *testing.T) {
+			g := Generator{KeyTransform: tt.keyTransform}
+			assert.Equal(t, tt.want, g.serviceKey(tt.name))
+		})
+	}
+}
+
+func TestGenerator_GenerateContractsFileTemplateModel_Keys(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_keys.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateContractsFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	// Person has nothing of its own to rename, but its pets do, and Tag has nothing at all
+	assert.Equal(t, []KeyMapTemplateModel{
+		{SchemaName: "Pet", Keys: []KeyTemplateModel{{Name: "petName", Key: "pet_name"}}},
+		{SchemaName: "Person", Keys: []KeyTemplateModel{{Name: "pets", Key: "pets", Nested: "Keys::Pet"}}},
+	}, model.KeyMaps)
+
+	requestContract, responseContract := model.Contracts[0], model.Contracts[1]
+	assert.Equal(t, []KeyTemplateModel{
+		{Name: "address", Key: "address", NestedKeys: []KeyTemplateModel{{Name: "zipCode", Key: "zip_code"}}},
+		{Name: "firstName", Key: "first_name"},
+		{Name: "pets", Key: "pets", Nested: "Keys::Pet"},
+		{Name: "personId", Key: "person_id"},
+		{Name: "dryRun", Key: "dry_run"},
+	}, requestContract.Keys)
+
+	assert.Equal(t, []KeyTemplateModel{{Name: "pets", Key: "pets", Nested: "Keys::Pet"}}, responseContract.Keys)
+}
+
+func TestGenerator_GenerateKeyMapTemplateModels_Cycle(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_factories.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	// Team isn't defined yet when User's KeyMap is, and not when its own is either
+	assert.Equal(t, []KeyMapTemplateModel{
+		{SchemaName: "User", Keys: []KeyTemplateModel{
+			{Name: "createdAt", Key: "created_at"},
+			{Name: "team", Key: "team", Nested: "-> { Keys::Team }"},
+		}},
+		{SchemaName: "Team", Keys: []KeyTemplateModel{
+			{Name: "members", Key: "members", Nested: "Keys::User"},
+			{Name: "parent", Key: "parent", Nested: "-> { Keys::Team }"},
+		}},
+	}, g.GenerateKeyMapTemplateModels())
+}
+
+func TestNewGeneratorFromConfig_KeyTransformNone(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_keys.yaml"
+	config.KeyTransform = KeyTransformNone
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateContractsFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	assert.Empty(t, model.KeyMaps)
+	assert.Empty(t, model.Contracts[0].Keys)
+	assert.Equal(t, "firstName", model.Contracts[0].Attributes[1].Key)
+}
+
+func TestWriter_ContractsFile_Keys(t *testing.T) {
+	outputDir := t.TempDir()
+	g, err := NewGenerator("fixtures/test_spec_keys.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	templateModels, err := g.GenerateTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating template models: %s\n", err)
+	}
+
+	w, err := NewWriter(outputDir, "TestApp", "", LayoutFlat)
+	if err != nil {
+		t.Fatalf("error creating writer: %s\n", err)
+	}
+
+	err = w.WriteFilesFromTemplateModels(templateModels)
+	if err != nil {
+		t.Fatalf("error writing files: %s\n", err)
+	}
+
+	contracts, err := os.ReadFile(filepath.Join(outputDir, "actions", "contracts.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(contracts), `before(:key_coercer) { |result| KEYS.call(result.to_h) }`)
+	assert.Contains(t, string(contracts), `required(:first_name).value(:string)`)
+	assert.Contains(t, string(contracts), `"petName" => [:pet_name],`)
+
+	serializers, err := os.ReadFile(filepath.Join(outputDir, "actions", "serializers.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(serializers), `["petName", :pet_name, :string],`)
+}
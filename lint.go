@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/deepmap/oapi-codegen/pkg/codegen"
+	"github.com/getkin/kin-openapi/openapi3"
+	"io"
+	"os"
+	"strings"
+)
+
+// LintSeverity is how bad a lint problem is. Errors stop generation, warnings don't.
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+// LintRule is one of the checks made by the lint subcommand.
+type LintRule struct {
+	ID          string
+	Description string
+	Severity    LintSeverity
+}
+
+var (
+	LintRuleInvalidSpec            = LintRule{"invalid-spec", "The spec can't be loaded, or isn't valid OpenAPI.", LintSeverityError}
+	LintRuleConversion             = LintRule{"conversion", "Part of a Swagger 2.0 or OpenAPI 3.1 spec doesn't carry over to OpenAPI 3.0 cleanly.", LintSeverityWarning}
+	LintRuleMissingTags            = LintRule{"missing-tags", "Operations need a tag (or under moduleNaming: path, a path segment that isn't a param), which decides the module their action is generated into.", LintSeverityError}
+	LintRuleAmbiguousTags          = LintRule{"ambiguous-tags", "Under tagPolicy: error or priority, operations with several tags need it to be clear which one is their module.", LintSeverityError}
+	LintRuleMissingOperationId     = LintRule{"missing-operation-id", "Operations without an operationId are named after their method and path.", LintSeverityWarning}
+	LintRuleInvalidOperationId     = LintRule{"invalid-operation-id", "operationIds must make a valid Ruby constant once camel cased.", LintSeverityError}
+	LintRuleMissingSuccessResponse = LintRule{"missing-success-response", "Operations need a 200 or 201 response, which their response contract is generated from.", LintSeverityError}
+	LintRuleUnsupportedMediaType   = LintRule{"unsupported-media-type", "Request bodies and 200/201 responses need application/json content.", LintSeverityError}
+	LintRuleUnsupportedSchema      = LintRule{"unsupported-schema", "Schemas need to be made of constructs that map onto dry-schema.", LintSeverityError}
+	LintRuleInvalidExtension       = LintRule{"invalid-extension", "x-hanami-* extensions need values of the right type and shape, e.g. a valid Ruby constant for x-hanami-action.", LintSeverityError}
+	LintRuleSnakeCaseCollision     = LintRule{"snake-case-collision", "Names that differ in the spec are the same once snake_cased, so clobber each other in the generated code.", LintSeverityError}
+)
+
+// LintRules lists every lint rule.
+var LintRules = []LintRule{
+	LintRuleInvalidSpec,
+	LintRuleConversion,
+	LintRuleMissingTags,
+	LintRuleAmbiguousTags,
+	LintRuleMissingOperationId,
+	LintRuleInvalidOperationId,
+	LintRuleMissingSuccessResponse,
+	LintRuleUnsupportedMediaType,
+	LintRuleUnsupportedSchema,
+	LintRuleInvalidExtension,
+	LintRuleSnakeCaseCollision,
+}
+
+// LintProblem is a place in a spec that breaks a LintRule.
+type LintProblem struct {
+	Rule     string       `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+	Diagnostic
+}
+
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%s: %s (%s)", p.Severity, p.Diagnostic, p.Rule)
+}
+
+// LintFormat is how lint problems are written out.
+type LintFormat string
+
+const (
+	LintFormatText  LintFormat = "text"
+	LintFormatJson  LintFormat = "json"
+	LintFormatSarif LintFormat = "sarif"
+)
+
+// lintRun implements the lint subcommand, which checks specs for everything that would stop generation, or
+// generate something surprising, and reports all of it at once.
+func lintRun(arguments []string) exitCode {
+	flags := flag.NewFlagSet("oapi-hanami-codegen lint", flag.ContinueOnError)
+	configFilePtr := flags.String("config", "", "path to a YAML or JSON config file, for its inputs and tags (defaults to ./oapi-hanami-codegen.yaml if it exists)")
+	inputFilePtr := flags.String("inputFile", "", "file path of OpenAPI spec, or a comma separated list of them (globs allowed), instead of the config's")
+	formatPtr := flags.String("format", string(LintFormatText), "output format: text, json or sarif")
+
+	err := flags.Parse(arguments)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing args: %s\n", err)
+		return exitError
+	}
+
+	format := LintFormat(*formatPtr)
+	if format != LintFormatText && format != LintFormatJson && format != LintFormatSarif {
+		fmt.Fprintf(os.Stderr, "error parsing args: unknown format %q, must be one of %s, %s, %s\n", format, LintFormatText, LintFormatJson, LintFormatSarif)
+		return exitError
+	}
+
+	config := defaultConfig()
+	configFile := *configFilePtr
+	if configFile == "" {
+		configFile = findConfigFile()
+	}
+	if configFile != "" {
+		config, err = loadConfigFile(configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error parsing args: %s\n", err)
+			return exitError
+		}
+	}
+	if inputs := splitList(*inputFilePtr); len(inputs) > 0 {
+		config.Input = ""
+		config.Inputs = inputs
+	}
+
+	inputFiles, err := config.InputFiles()
+	if err == nil && len(inputFiles) == 0 {
+		err = errors.New("must provide an OpenAPI spec to lint")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing args: %s\n", err)
+		return exitError
+	}
+
+	problems := lintSpecs(inputFiles, config)
+
+	err = writeLintProblems(os.Stdout, problems, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write lint problems: %s\n", err)
+		return exitError
+	}
+
+	for _, problem := range problems {
+		if problem.Severity == LintSeverityError {
+			return exitError
+		}
+	}
+
+	return exitOK
+}
+
+// lintSpecs lints every spec in inputFiles, taking into account the parts of config that change how operations
+// are generated, like Tags, Slices and OperationNaming.
+func lintSpecs(inputFiles []string, config *Config) []LintProblem {
+	l := &linter{config: config}
+	for _, inputFile := range inputFiles {
+		l.lintSpec(inputFile)
+	}
+
+	return l.problems
+}
+
+type linter struct {
+	config   *Config
+	problems []LintProblem
+}
+
+func (l *linter) report(rule LintRule, file string, pointer string, format string, args ...any) {
+	l.problems = append(l.problems, LintProblem{
+		Rule:       rule.ID,
+		Severity:   rule.Severity,
+		Diagnostic: Diagnostic{File: file, Pointer: pointer, Message: fmt.Sprintf(format, args...)},
+	})
+}
+
+// lintSpec goes through the same steps as loadSwagger, but carries on past problems to find the rest.
+func (l *linter) lintSpec(inputFile string) {
+	swagger, warnings, err := loadSpec(inputFile)
+	for _, warning := range warnings {
+		l.report(LintRuleConversion, warning.File, warning.Pointer, "%s", warning.Message)
+	}
+
+	var diagnosticsErr *DiagnosticsError
+	if errors.As(err, &diagnosticsErr) {
+		for _, diagnostic := range diagnosticsErr.Diagnostics {
+			l.report(LintRuleUnsupportedSchema, diagnostic.File, diagnostic.Pointer, "%s", diagnostic.Message)
+		}
+		return
+	}
+	if err != nil {
+		l.report(LintRuleInvalidSpec, inputFile, "", "%s", err)
+		return
+	}
+
+	err = swagger.Validate(context.Background())
+	if err != nil {
+		l.report(LintRuleInvalidSpec, inputFile, "", "%s", err)
+	}
+
+	internalizeRefs(swagger)
+
+	for _, diagnostic := range schemaExtensionDiagnostics(inputFile, swagger) {
+		l.report(LintRuleInvalidExtension, diagnostic.File, diagnostic.Pointer, "%s", diagnostic.Message)
+	}
+
+	l.lintOperations(inputFile, swagger)
+
+	if swagger.Components.Schemas != nil {
+		for _, name := range sortedKeys(swagger.Components.Schemas) {
+			l.lintSchema(inputFile, jsonPointer("components", "schemas", name), swagger.Components.Schemas[name], false)
+		}
+	}
+}
+
+// snakeCaseName is a name from the spec, remembered to check other names against once snake_cased.
+type snakeCaseName struct {
+	name    string
+	pointer string
+}
+
+func (l *linter) lintOperations(inputFile string, swagger *openapi3.T) {
+	defaultSliceName := l.config.SliceName
+	if l.config.SpecMode == SpecModeSlicePerSpec {
+		// a bad x-hanami-slice is reported as an invalid extension, and the file name will do in the meantime
+		defaultSliceName, _ = specSliceName(inputFile, swagger)
+	}
+
+	// names only clash within a slice, since each slice has its own actions
+	modules := map[string]map[string]snakeCaseName{}
+	actions := map[string]map[string]snakeCaseName{}
+
+	codegenOperationDefinitions, _ := codegenOperationDefinitionsFromSpec(swagger)
+	for i := range codegenOperationDefinitions {
+		// the request body and responses are linted as they are, rather than dug out as NewOperationDefinition
+		// would, so a problem with them doesn't hide the others
+		operationDefinition := &OperationDefinition{
+			OperationDefinition: &codegenOperationDefinitions[i],
+			ActionName:          codegenOperationDefinitions[i].OperationId,
+		}
+		operation := operationDefinition.Spec
+		requestPath := operationDefinition.Path
+		pointer := jsonPointer("paths", requestPath, strings.ToLower(operationDefinition.Method))
+		description := operationDefinition.Method + " " + requestPath
+
+		var err error
+		operationDefinition.HanamiExtensions, err = readOperationExtensions(operation.Extensions)
+		if err != nil {
+			for _, diagnostic := range extensionDiagnostics(inputFile, pointer, err) {
+				l.report(LintRuleInvalidExtension, diagnostic.File, diagnostic.Pointer, "%s", diagnostic.Message)
+			}
+		}
+
+		// skipped operations aren't generated, so can't cause problems
+		if operationDefinition.HanamiExtensions.Skip || l.config.skipsAnyOf(operation.Tags) {
+			continue
+		}
+
+		err = nameOperation(operationDefinition, defaultSliceName, l.config)
+
+		// names are compared as written in the spec, since getBooks and get_books are both GetBooks
+		specName := operation.OperationID
+		if specName == "" {
+			specName = description
+			if err == nil {
+				l.report(LintRuleMissingOperationId, inputFile, pointer, "%s has no operationId, so is called %s", description, operationDefinition.ActionName)
+			} else {
+				l.report(LintRuleMissingOperationId, inputFile, pointer, "%s has no operationId", description)
+			}
+		} else if !rubyConstantRegex.MatchString(codegen.ToCamelCase(operation.OperationID)) {
+			l.report(LintRuleInvalidOperationId, inputFile, pointer+jsonPointer("operationId"), "operationId %q of %s is %s once camel cased, which isn't a valid Ruby constant", operation.OperationID, description, codegen.ToCamelCase(operation.OperationID))
+		}
+
+		switch {
+		case err == nil:
+			sliceName := operationDefinition.SliceName
+			if modules[sliceName] == nil {
+				modules[sliceName] = map[string]snakeCaseName{}
+				actions[sliceName] = map[string]snakeCaseName{}
+			}
+			moduleName := operationDefinition.ModuleName
+			l.checkSnakeCase(modules[sliceName], inputFile, l.modulePointer(*operationDefinition, pointer), "module", moduleName, moduleKey(moduleName))
+			l.checkSnakeCase(actions[sliceName], inputFile, pointer, "operation", specName, moduleKey(moduleName)+"."+toSnake(operationDefinition.ActionName))
+		case errors.Is(err, ErrAmbiguousTags):
+			l.report(LintRuleAmbiguousTags, inputFile, pointer+jsonPointer("tags"), "%s has tags %s, and it's ambiguous which one is its module", description, strings.Join(operation.Tags, ", "))
+		case errors.Is(err, ErrNoPathModule):
+			l.report(LintRuleMissingTags, inputFile, pointer, "%s has no path segment that isn't a param, so there's no module to generate its action into", description)
+		default:
+			l.report(LintRuleMissingTags, inputFile, pointer, "%s has no tags, so there's no module to generate its action into", description)
+		}
+
+		l.lintRequestBody(inputFile, pointer, operation)
+		l.lintSuccessResponse(inputFile, pointer, description, operation)
+	}
+}
+
+// modulePointer points at what named an operation's module: its x-hanami-module, its path, or the tag it was
+// named after.
+func (l *linter) modulePointer(operationDefinition OperationDefinition, pointer string) string {
+	if operationDefinition.HanamiExtensions.Module != "" {
+		return pointer + jsonPointer(ExtensionModule)
+	}
+	if l.config.ModuleNaming == ModuleNamingPath {
+		return jsonPointer("paths", operationDefinition.Path)
+	}
+
+	tag, _ := safelyDigModuleName(operationDefinition.Spec.Tags, l.config)
+	for i := range operationDefinition.Spec.Tags {
+		if operationDefinition.Spec.Tags[i] == tag {
+			return pointer + jsonPointer("tags", fmt.Sprint(i))
+		}
+	}
+
+	return pointer
+}
+
+// checkSnakeCase reports name, at pointer, if a different name in names has the same snakeCase.
+func (l *linter) checkSnakeCase(names map[string]snakeCaseName, inputFile string, pointer string, kind string, name string, snakeCase string) {
+	other, ok := names[snakeCase]
+	if !ok {
+		names[snakeCase] = snakeCaseName{name: name, pointer: pointer}
+		return
+	}
+
+	if other.name != name {
+		l.report(LintRuleSnakeCaseCollision, inputFile, pointer, "%s %s and %s (at %s) are both %s once snake_cased", kind, name, other.name, other.pointer, snakeCase)
+	}
+}
+
+func (l *linter) lintRequestBody(inputFile string, pointer string, operation *openapi3.Operation) {
+	// the request contract holds both the params and the body
+	params := map[string]snakeCaseName{}
+	for i, parameter := range operation.Parameters {
+		if parameter.Value != nil {
+			l.checkSnakeCase(params, inputFile, pointer+jsonPointer("parameters", fmt.Sprint(i)), "parameter", parameter.Value.Name, toSnake(parameter.Value.Name))
+		}
+	}
+
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return
+	}
+
+	bodyPointer := pointer + jsonPointer("requestBody")
+	mediaType := operation.RequestBody.Value.Content.Get(MediaTypeJson)
+	if mediaType == nil {
+		l.report(LintRuleUnsupportedMediaType, inputFile, bodyPointer+jsonPointer("content"), "request body has no %s content, only %s", MediaTypeJson, strings.Join(sortedKeys(operation.RequestBody.Value.Content), ", "))
+		return
+	}
+	if mediaType.Schema == nil {
+		return
+	}
+
+	schemaPointer := bodyPointer + jsonPointer("content", MediaTypeJson, "schema")
+	if mediaType.Schema.Value != nil {
+		for _, name := range sortedKeys(mediaType.Schema.Value.Properties) {
+			l.checkSnakeCase(params, inputFile, schemaPointer+jsonPointer("properties", name), "request body property", name, toSnake(name))
+		}
+	}
+	l.lintSchema(inputFile, schemaPointer, mediaType.Schema, false)
+}
+
+func (l *linter) lintSuccessResponse(inputFile string, pointer string, description string, operation *openapi3.Operation) {
+	status := "200"
+	response := operation.Responses.Get(200)
+	if response == nil {
+		status = "201"
+		response = operation.Responses.Get(201)
+	}
+	if response == nil {
+		l.report(LintRuleMissingSuccessResponse, inputFile, pointer+jsonPointer("responses"), "%s has no 200 or 201 response", description)
+		return
+	}
+	if response.Value == nil {
+		return
+	}
+
+	responsePointer := pointer + jsonPointer("responses", status)
+	mediaType := response.Value.Content.Get(MediaTypeJson)
+	if mediaType == nil {
+		if len(response.Value.Content) == 0 {
+			l.report(LintRuleUnsupportedMediaType, inputFile, responsePointer, "%s response has no content, it needs %s", status, MediaTypeJson)
+		} else {
+			l.report(LintRuleUnsupportedMediaType, inputFile, responsePointer+jsonPointer("content"), "%s response has no %s content, only %s", status, MediaTypeJson, strings.Join(sortedKeys(response.Value.Content), ", "))
+		}
+		return
+	}
+	if mediaType.Schema != nil {
+		l.lintSchema(inputFile, responsePointer+jsonPointer("content", MediaTypeJson, "schema"), mediaType.Schema, false)
+	}
+}
+
+// lintUnsupportedKeywords are schema keywords generateAttributeDefinition has no mapping for.
+var lintUnsupportedKeywords = []string{"allOf", "anyOf", "oneOf", "not"}
+
+// lintSchema checks the schema at pointer, and everything in it, maps onto dry-schema. Refs are checked where
+// they're defined instead. nested says whether the schema is a property or array items, which need a type, as
+// opposed to a whole schema, which only needs properties.
+func (l *linter) lintSchema(inputFile string, pointer string, schemaRef *openapi3.SchemaRef, nested bool) {
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return
+	}
+	schema := schemaRef.Value
+
+	// skipped schemas aren't generated, so can't cause problems
+	if extensions, _ := readSchemaExtensions(schema.Extensions); extensions.Skip {
+		return
+	}
+
+	unsupported := false
+	for _, keyword := range lintUnsupportedKeywords {
+		if schemaKeywordSet(schema, keyword) {
+			unsupported = true
+			l.report(LintRuleUnsupportedSchema, inputFile, pointer+jsonPointer(keyword), "%s isn't supported, use a single schema", keyword)
+		}
+	}
+
+	if nested && schema.Type == "" && !unsupported {
+		l.report(LintRuleUnsupportedSchema, inputFile, pointer, "schema has no type, so there's nothing to validate it as")
+	}
+
+	properties := map[string]snakeCaseName{}
+	for _, name := range sortedKeys(schema.Properties) {
+		propertyPointer := pointer + jsonPointer("properties", name)
+		l.checkSnakeCase(properties, inputFile, propertyPointer, "property", name, toSnake(name))
+		l.lintSchema(inputFile, propertyPointer, schema.Properties[name], true)
+	}
+
+	if schema.Type == "array" {
+		l.lintSchema(inputFile, pointer+jsonPointer("items"), schema.Items, true)
+	}
+}
+
+func schemaKeywordSet(schema *openapi3.Schema, keyword string) bool {
+	switch keyword {
+	case "allOf":
+		return len(schema.AllOf) > 0
+	case "anyOf":
+		return len(schema.AnyOf) > 0
+	case "oneOf":
+		return len(schema.OneOf) > 0
+	case "not":
+		return schema.Not != nil
+	}
+	return false
+}
+
+// writeLintProblems writes problems to w in format.
+func writeLintProblems(w io.Writer, problems []LintProblem, format LintFormat) error {
+	switch format {
+	case LintFormatJson:
+		if problems == nil {
+			problems = []LintProblem{}
+		}
+		return writeJson(w, struct {
+			Problems []LintProblem `json:"problems"`
+		}{problems})
+	case LintFormatSarif:
+		return writeJson(w, newSarifLog(problems))
+	default:
+		return writeLintText(w, problems)
+	}
+}
+
+func writeLintText(w io.Writer, problems []LintProblem) error {
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Severity == LintSeverityError {
+			errorCount++
+		}
+		_, err := fmt.Fprintln(w, problem)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d problems (%d errors, %d warnings)\n", len(problems), errorCount, len(problems)-errorCount)
+	return err
+}
+
+func writeJson(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
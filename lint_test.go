@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLintSpecs(t *testing.T) {
+	problems := lintSpecs([]string{"fixtures/lint/spec.yaml"}, defaultConfig())
+
+	type location struct {
+		rule    string
+		pointer string
+	}
+	var locations []location
+	for _, problem := range problems {
+		assert.Equal(t, "fixtures/lint/spec.yaml", problem.File)
+		locations = append(locations, location{problem.Rule, problem.Pointer})
+	}
+
+	assert.Equal(t, []location{
+		{"invalid-operation-id", "/paths/~1authors/get/operationId"},
+		{"missing-tags", "/paths/~1authors/get"},
+		{"snake-case-collision", "/paths/~1authors/post/requestBody/content/application~1json/schema/properties/author_id"},
+		{"snake-case-collision", "/paths/~1books/post"},
+		{"unsupported-media-type", "/paths/~1books/post/requestBody/content"},
+		{"unsupported-media-type", "/paths/~1books/post/responses/201/content"},
+		{"missing-operation-id", "/paths/~1books~1{bookId}/get"},
+		{"snake-case-collision", "/paths/~1books~1{bookId}/get/tags/0"},
+		{"missing-success-response", "/paths/~1books~1{bookId}/get/responses"},
+		{"unsupported-schema", "/components/schemas/Book/properties/extra"},
+		{"unsupported-schema", "/components/schemas/Book/properties/format/oneOf"},
+		{"snake-case-collision", "/components/schemas/Book/properties/title"},
+	}, locations)
+
+	assert.Equal(t, LintSeverityWarning, problems[6].Severity)
+	assert.Equal(t, "operation get_books and getBooks (at /paths/~1books/get) are both books.get_books once snake_cased", problems[3].Message)
+}
+
+func TestLintSpecs_TagConfig(t *testing.T) {
+	config := defaultConfig()
+	config.Tags = map[string]TagConfig{
+		"books": {Skip: true},
+		"Books": {Module: "Library"},
+	}
+	problems := lintSpecs([]string{"fixtures/lint/spec.yaml"}, config)
+
+	for _, problem := range problems {
+		assert.NotContains(t, problem.Pointer, "/paths/~1books/")
+		assert.NotEqual(t, "/paths/~1books~1{bookId}/get/tags/0", problem.Pointer)
+	}
+}
+
+func TestLintSpecs_PathModules(t *testing.T) {
+	config := defaultConfig()
+	config.ModuleNaming = ModuleNamingPath
+	problems := lintSpecs([]string{"fixtures/test_spec_nested.yaml"}, config)
+
+	// GET /authors has no tags, but doesn't need any
+	for _, problem := range problems {
+		assert.NotEqual(t, LintRuleMissingTags.ID, problem.Rule)
+	}
+}
+
+func TestLintSpecs_AmbiguousTags(t *testing.T) {
+	config := defaultConfig()
+	config.TagPolicy = TagPolicyError
+	problems := lintSpecs([]string{"fixtures/test_spec_multiple_tags.yaml"}, config)
+
+	var pointers []string
+	for _, problem := range problems {
+		if problem.Rule == LintRuleAmbiguousTags.ID {
+			pointers = append(pointers, problem.Pointer)
+		}
+	}
+	// list-featured-books has an x-hanami-module, so isn't ambiguous
+	assert.Equal(t, []string{"/paths/~1authors/get/tags", "/paths/~1books/get/tags"}, pointers)
+}
+
+func TestLintSpecs_Clean(t *testing.T) {
+	assert.Empty(t, lintSpecs([]string{"fixtures/test_spec.yaml"}, defaultConfig()))
+}
+
+func TestLintSpecs_ConvertedSpecs(t *testing.T) {
+	problems := lintSpecs([]string{"fixtures/swagger2/petstore.yaml", "fixtures/openapi31/unsupported.yaml"}, defaultConfig())
+
+	var rules []string
+	for _, problem := range problems {
+		rules = append(rules, problem.Rule)
+	}
+	assert.Equal(t, []string{"conversion", "conversion", "conversion", "conversion", "conversion", "unsupported-schema", "unsupported-schema"}, rules)
+	assert.Equal(t, "/components/schemas/Thing/properties/id/type", problems[5].Pointer)
+}
+
+func TestLintSpecs_InvalidSpec(t *testing.T) {
+	problems := lintSpecs([]string{"fixtures/test_spec_invalid.yaml"}, defaultConfig())
+	assert.Equal(t, "invalid-spec", problems[0].Rule)
+	assert.Equal(t, "", problems[0].Pointer)
+}
+
+func TestWriteLintProblems(t *testing.T) {
+	problems := []LintProblem{
+		{Rule: "missing-tags", Severity: LintSeverityError, Diagnostic: Diagnostic{"spec.yaml", "/paths/~1books/get", "GET /books has no tags"}},
+		{Rule: "missing-operation-id", Severity: LintSeverityWarning, Diagnostic: Diagnostic{"spec.yaml", "/paths/~1books/get", "GET /books has no operationId"}},
+	}
+
+	var text bytes.Buffer
+	assert.NoError(t, writeLintProblems(&text, problems, LintFormatText))
+	assert.Equal(t, `error: spec.yaml#/paths/~1books/get: GET /books has no tags (missing-tags)
+warning: spec.yaml#/paths/~1books/get: GET /books has no operationId (missing-operation-id)
+2 problems (1 errors, 1 warnings)
+`, text.String())
+
+	var jsonOutput bytes.Buffer
+	assert.NoError(t, writeLintProblems(&jsonOutput, problems[:1], LintFormatJson))
+	assert.JSONEq(t, `{"problems": [{"rule": "missing-tags", "severity": "error", "file": "spec.yaml", "pointer": "/paths/~1books/get", "message": "GET /books has no tags"}]}`, jsonOutput.String())
+
+	var sarifOutput bytes.Buffer
+	assert.NoError(t, writeLintProblems(&sarifOutput, problems[:1], LintFormatSarif))
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(sarifOutput.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(LintRules))
+	assert.Equal(t, sarifResult{
+		RuleID:  "missing-tags",
+		Level:   "error",
+		Message: sarifMessage{Text: "GET /books has no tags"},
+		Locations: []sarifLocation{
+			{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "spec.yaml"}},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/paths/~1books/get"}},
+			},
+		},
+	}, log.Runs[0].Results[0])
+}
+
+func TestLintSpecs_Slices(t *testing.T) {
+	config := defaultConfig()
+	config.Layout = LayoutHanami
+	config.SliceName = "API"
+	config.Slices = map[string]SliceConfig{"Admin": {PathPrefix: "/admin"}}
+	config.Tags = map[string]TagConfig{"people": {Module: "users"}}
+	problems := lintSpecs([]string{"fixtures/lint/slices.yaml"}, config)
+
+	// list-books and list_books are in different slices, so don't clash, but /admin/people is in the users module
+	// of the Admin slice too, so listUsers and list_users do
+	assert.Equal(t, []LintProblem{{
+		Rule:     LintRuleSnakeCaseCollision.ID,
+		Severity: LintSeverityError,
+		Diagnostic: Diagnostic{
+			File:    "fixtures/lint/slices.yaml",
+			Pointer: "/paths/~1admin~1users/get",
+			Message: "operation listUsers and list_users (at /paths/~1admin~1people/get) are both users.list_users once snake_cased",
+		},
+	}}, problems)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"net/url"
+	"os"
+)
+
+var ErrRemoteRef = errors.New("remote references aren't supported, only files relative to the spec")
+
+// loadSpec loads the spec at filePath, following $refs to other local files relative to the file they're in,
+// e.g. `$ref: ./schemas/pet.yaml#/Pet`. The tool runs offline, so specs and refs over http fail straight away.
+//
+// Swagger 2.0 specs are converted to OpenAPI 3 on the way in, and OpenAPI 3.1 specs downgraded to 3.0, and the
+// returned warnings say what didn't carry over cleanly, see convertSwagger2 and openAPI31Downgrade.
+func loadSpec(filePath string) (*openapi3.T, []Diagnostic, error) {
+	if isRemoteURL(filePath) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRemoteRef, filePath)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isSwagger2(data) {
+		return convertSwagger2(filePath, data)
+	}
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = readLocalFile
+
+	if !isOpenAPI31(data) {
+		swagger, err := loader.LoadFromFile(filePath)
+		return swagger, nil, err
+	}
+
+	downgrade := newOpenAPI31Downgrade()
+	loader.ReadFromURIFunc = downgrade.readFile
+	swagger, err := loader.LoadFromFile(filePath)
+	// unsupported constructs are likely why loading failed, if it did
+	if downgradeErr := downgrade.err(); downgradeErr != nil {
+		return nil, nil, downgradeErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return swagger, downgrade.warnings, nil
+}
+
+// internalizeRefs moves schemas and the like from external refs into the spec's components, named after the
+// last part of the ref (Pet for ./schemas/pet.yaml#/Pet), so the rest of the generator only ever sees local refs.
+func internalizeRefs(swagger *openapi3.T) {
+	swagger.InternalizeRefs(context.Background(), nil)
+}
+
+func readLocalFile(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	if location.Host != "" || (location.Scheme != "" && location.Scheme != "file") {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteRef, location)
+	}
+
+	return openapi3.ReadFromFile(loader, location)
+}
+
+func isRemoteURL(filePath string) bool {
+	u, err := url.Parse(filePath)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadSwagger_ExternalRefs(t *testing.T) {
+	swagger, _, err := loadSwagger("fixtures/external_refs/spec.yaml")
+	if err != nil {
+		t.Fatalf("error loading spec: %s\n", err)
+	}
+
+	assert.Contains(t, swagger.Components.Schemas, "Pet")
+	assert.Contains(t, swagger.Components.Schemas, "Owner")
+	assert.Equal(t, "#/components/schemas/Pet", swagger.Paths["/pets"].Get.Responses.Get(200).Value.Content.Get(MediaTypeJson).Schema.Value.Properties["pet"].Ref)
+	assert.Equal(t, "#/components/schemas/Owner", swagger.Components.Schemas["Pet"].Value.Properties["owner"].Ref)
+}
+
+func TestLoadSpec_RemoteRefs(t *testing.T) {
+	_, _, err := loadSpec("fixtures/external_refs/remote.yaml")
+	assert.ErrorIs(t, err, ErrRemoteRef)
+
+	_, _, err = loadSpec("https://example.com/spec.yaml")
+	assert.ErrorIs(t, err, ErrRemoteRef)
+}
+
+func TestGenerator_ExternalRefs(t *testing.T) {
+	g, err := NewGenerator("fixtures/external_refs/spec.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateContractsFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	assert.Equal(t, "Schemas::Pet", model.Contracts[1].Attributes[0].AttributeType)
+}
+
+func TestMarshalSpec(t *testing.T) {
+	swagger, _, err := loadSwagger("fixtures/external_refs/spec.yaml")
+	if err != nil {
+		t.Fatalf("error loading spec: %s\n", err)
+	}
+
+	data, err := marshalSpec(swagger, false)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "$ref: '#/components/schemas/Owner'")
+	assert.NotContains(t, string(data), "pet.yaml")
+}
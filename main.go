@@ -20,17 +20,37 @@ func main() {
 }
 
 func mainRun() exitCode {
-	config, err := parseArgs()
+	arguments := os.Args[1:]
+	if len(arguments) > 0 {
+		switch arguments[0] {
+		case "generate":
+			return generateRun(arguments[1:])
+		case "bundle":
+			return bundleRun(arguments[1:])
+		case "lint":
+			return lintRun(arguments[1:])
+		}
+	}
+
+	return generateRun(arguments)
+}
+
+func generateRun(arguments []string) exitCode {
+	config, err := parseArgs(arguments)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error parsing args: %s\n", err)
 		return exitError
 	}
 
-	g, err := NewGenerator(config.inputFilePath, config.appName, config.sliceName)
+	g, err := NewGeneratorFromConfig(config)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create generator: %s\n", err)
 		return exitError
 	}
+	printWarnings(g.Warnings)
 
 	templateModels, err := g.GenerateTemplateModels()
 	if err != nil {
@@ -38,7 +58,7 @@ func mainRun() exitCode {
 		return exitError
 	}
 
-	w, err := NewWriter(config.outputDir, config.appName)
+	w, err := NewWriter(config.OutputDir, config.AppName, config.TemplatesDir, config.Layout)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create a new writer: %s\n", err)
 		return exitError
@@ -53,29 +73,107 @@ func mainRun() exitCode {
 	return exitOK
 }
 
-type args struct {
-	inputFilePath string
-	appName       string
-	sliceName     string
-	outputDir     string
+func printWarnings(warnings []Diagnostic) {
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
 }
 
-func parseArgs() (*args, error) {
-	inputFilePtr := flag.String("inputFile", "", "file path of OpenAPI spec")
-	appNamePtr := flag.String("appName", "HanamiApp", "name of the top-level Hanami app module")
-	sliceNamePtr := flag.String("sliceName", "API", "name of the slice you want to put your generated actions in")
-	outputDirPtr := flag.String("outputDir", "gen", "path to output directory")
+// parseArgs builds the Config for this run: the config file (if there is one), with any flags given on the
+// command line taking precedence.
+func parseArgs(arguments []string) (*Config, error) {
+	defaults := defaultConfig()
+	flags := flag.NewFlagSet("oapi-hanami-codegen", flag.ContinueOnError)
+	configFilePtr := flags.String("config", "", "path to a YAML or JSON config file (defaults to ./oapi-hanami-codegen.yaml if it exists)")
+	inputFilePtr := flags.String("inputFile", "", "file path of OpenAPI spec, or a comma separated list of paths and globs to combine several")
+	specModePtr := flags.String("specMode", string(defaults.SpecMode), "how to combine several specs: merge them into the same slices, or slice-per-spec")
+	appNamePtr := flags.String("appName", defaults.AppName, "name of the top-level Hanami app module")
+	sliceNamePtr := flags.String("sliceName", defaults.SliceName, "name of the slice you want to put your generated actions in")
+	outputDirPtr := flags.String("outputDir", defaults.OutputDir, "path to output directory")
+	templatesDirPtr := flags.String("templatesDir", "", "path to a directory of templates that override the built-in ones by name")
+	generatePtr := flags.String("generate", "", "comma separated list of artifacts to generate, from: "+joinArtifacts(AllArtifacts)+" (default all but the opt-in client, middleware and mock)")
+	excludePtr := flags.String("exclude", "", "comma separated list of artifacts not to generate")
+	layoutPtr := flags.String("layout", string(defaults.Layout), "output layout: flat, or hanami to generate into an existing Hanami 2 app at outputDir")
+	operationNamingPtr := flags.String("operationNaming", string(defaults.OperationNaming), "what to call operations without an operationId: path (e.g. GetBooksBookId), or rest (e.g. books.show)")
+	moduleNamingPtr := flags.String("moduleNaming", string(defaults.ModuleNaming), "which module operations go in: tag (e.g. Books), or path to nest them after their path (e.g. Authors::Books)")
+	tagPolicyPtr := flags.String("tagPolicy", string(defaults.TagPolicy), "which tag names the module of an operation with several: first, error to refuse to guess, or priority to go by -tagPriority")
+	tagPriorityPtr := flags.String("tagPriority", "", "comma separated list of tags, most preferred first, for -tagPolicy=priority")
+	persistPtr := flags.String("persist", "", "comma separated list of component schemas to generate ROM relations, repositories and entities for")
+	migrationsPtr := flags.Bool("migrations", false, "draft a migration creating the table of each persisted schema")
+	requestStructsPtr := flags.Bool("requestStructs", false, "have actions call their services with a request struct from structs.rb rather than a hash of the params")
+	keyTransformPtr := flags.String("keyTransform", string(defaults.KeyTransform), "what services call properties and params: snake (e.g. first_name), camel (e.g. firstName), or none to keep their names in the spec")
+	middlewareModePtr := flags.String("middlewareMode", string(defaults.MiddlewareMode), "what the validation middleware does with requests and responses that don't match the spec: warn, or enforce to reject them")
+	decimalsPtr := flags.String("decimals", string(defaults.Decimals), "how response serializers write decimals: number, or string to keep their precision")
+
+	err := flags.Parse(arguments)
+	if err != nil {
+		return nil, err
+	}
 
-	flag.Parse()
+	configFilePath := *configFilePtr
+	if configFilePath == "" {
+		configFilePath = findConfigFile()
+	}
 
-	if *inputFilePtr == "" {
-		return nil, errors.New("must provide an inputFile")
+	config := defaults
+	if configFilePath != "" {
+		config, err = loadConfigFile(configFilePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// only flags that were actually given override the config file, otherwise their defaults would
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "inputFile":
+			config.Input, config.Inputs = "", splitList(*inputFilePtr)
+			if len(config.Inputs) == 1 {
+				config.Input, config.Inputs = config.Inputs[0], nil
+			}
+		case "specMode":
+			config.SpecMode = SpecMode(*specModePtr)
+		case "appName":
+			config.AppName = *appNamePtr
+		case "sliceName":
+			config.SliceName = *sliceNamePtr
+		case "outputDir":
+			config.OutputDir = *outputDirPtr
+		case "templatesDir":
+			config.TemplatesDir = *templatesDirPtr
+		case "generate":
+			config.Generate = splitList(*generatePtr)
+		case "exclude":
+			config.Exclude = splitList(*excludePtr)
+		case "layout":
+			config.Layout = Layout(*layoutPtr)
+		case "operationNaming":
+			config.OperationNaming = OperationNaming(*operationNamingPtr)
+		case "moduleNaming":
+			config.ModuleNaming = ModuleNaming(*moduleNamingPtr)
+		case "tagPolicy":
+			config.TagPolicy = TagPolicy(*tagPolicyPtr)
+		case "tagPriority":
+			config.TagPriority = splitList(*tagPriorityPtr)
+		case "persist":
+			config.Persist = splitList(*persistPtr)
+		case "migrations":
+			config.Migrations = *migrationsPtr
+		case "requestStructs":
+			config.RequestStructs = *requestStructsPtr
+		case "decimals":
+			config.Decimals = DecimalFormat(*decimalsPtr)
+		case "keyTransform":
+			config.KeyTransform = KeyTransform(*keyTransformPtr)
+		case "middlewareMode":
+			config.MiddlewareMode = MiddlewareMode(*middlewareModePtr)
+		}
+	})
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
 	}
 
-	return &args{
-		inputFilePath: *inputFilePtr,
-		appName:       *appNamePtr,
-		sliceName:     *sliceNamePtr,
-		outputDir:     *outputDirPtr,
-	}, nil
+	return config, nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// MiddlewareMode is what the validation middleware does with a request or response that doesn't match the spec.
+type MiddlewareMode string
+
+const (
+	// MiddlewareModeWarn logs it and lets it through, for adopting the spec on endpoints that may not match it yet.
+	MiddlewareModeWarn MiddlewareMode = "warn"
+	// MiddlewareModeEnforce responds 422 to a bad request, without calling the app, and 500 to a bad response.
+	MiddlewareModeEnforce MiddlewareMode = "enforce"
+)
+
+// MiddlewareTemplateModel is a slice's Rack middleware, which checks any request and response against the spec
+// before it reaches the app, using the contracts in contracts.rb, so it works in front of actions that weren't
+// generated, or were generated before the spec said what it says now.
+type MiddlewareTemplateModel struct {
+	AppName   string
+	SliceName string
+	// Mode is the middleware's mode unless it's given one when it's mounted.
+	Mode MiddlewareMode
+	// Routes is the route table the middleware finds a request's operation in.
+	Routes []MiddlewareRouteTemplateModel
+	// Requires are the paths of the slice's schemas.rb and contracts.rb relative to the middleware, without .rb, in
+	// the flat layout. The Writer fills them in, as it does for the client; Hanami autoloads them otherwise.
+	Requires []string
+}
+
+type MiddlewareRouteTemplateModel struct {
+	// Method and Path are the operation's, with Path including the slice's mount, e.g. GET /api/pets/{petId}.
+	Method string
+	Path   string
+	// OperationId names the operation's contracts, e.g. GetPetById for GetPetByIdRequestContract.
+	OperationId string
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+// GenerateMiddlewareTemplateModels generates the validation middleware for each slice.
+func (g Generator) GenerateMiddlewareTemplateModels() ([]MiddlewareTemplateModel, error) {
+	mode := g.MiddlewareMode
+	if mode == "" {
+		mode = MiddlewareModeWarn
+	}
+
+	var middlewareTemplateModels []MiddlewareTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		var routes []MiddlewareRouteTemplateModel
+		for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+			routes = append(routes, MiddlewareRouteTemplateModel{
+				Method:      operationDefinition.Method,
+				Path:        g.sliceMount(sliceName) + strings.TrimSuffix(g.routePath(operationDefinition), "/"),
+				OperationId: operationDefinition.OperationId,
+				Extensions:  decodedExtensions(operationDefinition.Spec.Extensions),
+			})
+		}
+
+		// the middleware takes the first route that matches, so /pets/mine has to come before /pets/{petId}
+		sort.SliceStable(routes, func(i, j int) bool {
+			return strings.Count(routes[i].Path, "{") < strings.Count(routes[j].Path, "{")
+		})
+
+		middlewareTemplateModels = append(middlewareTemplateModels, MiddlewareTemplateModel{
+			AppName:   g.AppName,
+			SliceName: sliceName,
+			Mode:      mode,
+			Routes:    routes,
+		})
+	}
+
+	return middlewareTemplateModels, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_GenerateMiddlewareTemplateModels(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_rest.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	middlewareTemplateModels, err := g.GenerateMiddlewareTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating middleware template models: %s\n", err)
+	}
+	assert.Len(t, middlewareTemplateModels, 1)
+
+	middleware := middlewareTemplateModels[0]
+	assert.Equal(t, MiddlewareModeWarn, middleware.Mode)
+	assert.Len(t, middleware.Routes, 10)
+	// /books/new comes before /books/{bookId}, which would match it too
+	assert.Equal(t, MiddlewareRouteTemplateModel{Method: "GET", Path: "/api/books/new", OperationId: "GetBooksNew"}, middleware.Routes[2])
+	assert.Equal(t, MiddlewareRouteTemplateModel{Method: "DELETE", Path: "/api/books/{bookId}", OperationId: "DeleteBooksBookId"}, middleware.Routes[3])
+}
+
+func TestConfig_Artifacts_MiddlewareIsOptIn(t *testing.T) {
+	assert.False(t, (&Config{}).Artifacts().Includes(ArtifactMiddleware))
+	assert.True(t, (&Config{Generate: []string{"middleware"}}).Artifacts().Includes(ArtifactMiddleware))
+}
+
+func TestWriter_Middleware(t *testing.T) {
+	for _, tt := range []struct {
+		layout   Layout
+		filePath string
+		requires bool
+	}{
+		{LayoutFlat, "middleware/validation.rb", true},
+		{LayoutHanami, "slices/catalogue/middleware/validation.rb", false},
+	} {
+		t.Run(string(tt.layout), func(t *testing.T) {
+			outputDir := generateInto(t, &Config{
+				Input:          "fixtures/test_spec_examples.yaml",
+				AppName:        "Bookshop",
+				SliceName:      "Catalogue",
+				Generate:       []string{"middleware"},
+				MiddlewareMode: MiddlewareModeEnforce,
+			}, tt.layout)
+
+			middleware, err := os.ReadFile(filepath.Join(outputDir, tt.filePath))
+			assert.NoError(t, err)
+			assert.Contains(t, string(middleware), "module Catalogue\n  module Middleware\n")
+			assert.Contains(t, string(middleware), `route("GET", "/catalogue/books/{bookId}/reviews", :ListReviews),`)
+			assert.Contains(t, string(middleware), "def initialize(app, mode: :enforce, logger: Logger.new($stderr))")
+			if tt.requires {
+				assert.Contains(t, string(middleware), `require_relative "../actions/contracts"`)
+			} else {
+				assert.NotContains(t, string(middleware), "require_relative")
+			}
+		})
+	}
+}
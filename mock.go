@@ -0,0 +1,16 @@
+package main
+
+// mockResponse is a Ruby literal of the response body a mock service returns for an operation: the 200 (or 201)
+// response's example, or one of its examples, or failing that one made up from its schema, as for request specs.
+// It's keyed the way services key what they return, see factoryLiteral, for the serializers to name back.
+func (g Generator) mockResponse(operationDefinition OperationDefinition) string {
+	response := operationDefinition.Spec.Responses.Get(200)
+	if response == nil {
+		response = operationDefinition.Spec.Responses.Get(201)
+	}
+	if response == nil || response.Value == nil || response.Value.Content.Get(MediaTypeJson) == nil {
+		return "{}"
+	}
+
+	return factoryLiteral(mediaTypeExample(response.Value.Content.Get(MediaTypeJson)), g.serviceKey)
+}
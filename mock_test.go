@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_mockResponse(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_mock.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	mockResponses := map[string]string{}
+	for _, operationDefinition := range g.OperationDefinitions {
+		mockResponses[operationDefinition.OperationId] = g.mockResponse(operationDefinition)
+	}
+
+	// the first of the response's examples by name, keyed as services key it
+	assert.Equal(t, `{ pets: [{ name: "Rex" }] }`, mockResponses["ListPets"])
+	// a 201's, from its schema's example
+	assert.Equal(t, `{ birth_date: "2019-01-01", name: "Fido" }`, mockResponses["CreatePet"])
+	// made up from the schema
+	assert.Equal(t, `{ age: 3, last_visit: "2024-01-01T00:00:00Z", name: "string" }`, mockResponses["GetPet"])
+}
+
+func TestConfig_Artifacts_MockIsOptIn(t *testing.T) {
+	assert.False(t, (&Config{}).Artifacts().Includes(ArtifactMock))
+	assert.True(t, (&Config{Generate: []string{"actions", "mock"}}).Artifacts().Includes(ArtifactMock))
+}
+
+func TestWriter_MockServices(t *testing.T) {
+	config := func(generate ...string) *Config {
+		return &Config{
+			Input:     "fixtures/test_spec_mock.yaml",
+			AppName:   "Petshop",
+			SliceName: "API",
+			Generate:  generate,
+		}
+	}
+
+	// a default run writes the empty services first, and one of them gets filled in
+	outputDir := generateInto(t, config(), LayoutFlat)
+	filledInServicePath := filepath.Join(outputDir, "services", "pets", "get_pet.rb")
+	assert.NoError(t, os.WriteFile(filledInServicePath, []byte("# ours"), 0644))
+
+	mockConfig := config("actions", "mock")
+	mockConfig.OutputDir = outputDir
+	generateInto(t, mockConfig, LayoutFlat)
+
+	// the mock takes the place of the empty service
+	service, err := os.ReadFile(filepath.Join(outputDir, "services", "pets", "create_pet.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(service), `Success({ birth_date: "2019-01-01", name: "Fido" })`)
+
+	// but like any service, a mock isn't written over one that's been filled in
+	filledInService, err := os.ReadFile(filledInServicePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "# ours", string(filledInService))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"github.com/deepmap/oapi-codegen/pkg/codegen"
+	"net/http"
+	"strings"
+)
+
+// OperationNaming decides what operations without an operationId are called.
+type OperationNaming string
+
+const (
+	// OperationNamingPath names operations after their method and path, e.g. GetBooksBookId for
+	// GET /books/{bookId}.
+	OperationNamingPath OperationNaming = "path"
+	// OperationNamingRest gives operations Hanami's RESTful action names, based on their method and the shape
+	// of their path, e.g. books.show for GET /books/{bookId}. See restActionName.
+	OperationNamingRest OperationNaming = "rest"
+)
+
+// ModuleNaming decides which module an operation's action and service are generated into.
+type ModuleNaming string
+
+const (
+	// ModuleNamingTag uses the operation's tag, e.g. Books for an operation tagged books.
+	ModuleNamingTag ModuleNaming = "tag"
+	// ModuleNamingPath nests modules after the resources in the operation's path, e.g. Authors::Books for
+	// /authors/{authorId}/books. See pathModuleName.
+	ModuleNamingPath ModuleNaming = "path"
+)
+
+// TagPolicy decides which tag names the module of an operation with more than one.
+type TagPolicy string
+
+const (
+	// TagPolicyFirst uses the first tag listed.
+	TagPolicyFirst TagPolicy = "first"
+	// TagPolicyError refuses to pick, so operations with several tags need an x-hanami-module extension.
+	TagPolicyError TagPolicy = "error"
+	// TagPolicyPriority uses whichever of the tags comes first in the config's TagPriority, and errors like
+	// TagPolicyError if none of them are listed.
+	TagPolicyPriority TagPolicy = "priority"
+)
+
+// restActionName is the RESTful action name for an operation on requestPath in the module moduleName, or "" if
+// it isn't one of the standard shapes:
+//
+//	GET    /books           Index
+//	GET    /books/new       New
+//	POST   /books           Create
+//	GET    /books/{id}      Show
+//	GET    /books/{id}/edit Edit
+//	PUT    /books/{id}      Update (PATCH too)
+//	DELETE /books/{id}      Destroy
+//
+// A path that goes on past a param is a nested collection if the rest is the module's own name, so
+// GET /authors/{id}/books in the books module is an Index, and a custom action named after the rest otherwise,
+// so POST /books/{id}/publish is Publish.
+func restActionName(method string, requestPath string, moduleName string) string {
+	segments := pathSegments(requestPath)
+
+	last := ""
+	if len(segments) > 0 {
+		last = segments[len(segments)-1]
+	}
+	member := isPathParam(last)
+	afterMember := len(segments) > 1 && isPathParam(segments[len(segments)-2])
+
+	parts := moduleParts(moduleName)
+	ownCollection := toSnake(last) == toSnake(parts[len(parts)-1])
+
+	switch {
+	case method == http.MethodGet && last == "new" && !afterMember:
+		return "New"
+	case method == http.MethodGet && last == "edit" && afterMember:
+		return "Edit"
+	case member:
+		switch method {
+		case http.MethodGet:
+			return "Show"
+		case http.MethodPut, http.MethodPatch:
+			return "Update"
+		case http.MethodDelete:
+			return "Destroy"
+		}
+	case afterMember && !ownCollection:
+		return codegen.ToCamelCase(last)
+	case method == http.MethodGet:
+		return "Index"
+	case method == http.MethodPost:
+		return "Create"
+	}
+
+	return ""
+}
+
+// pathModuleName nests a module for each resource in requestPath, i.e. each segment that isn't a param, so
+// /authors/{authorId}/books is Authors::Books. The new and edit pages of a resource stay in its module, so
+// /books/new and /books/{bookId}/edit are both Books. It's "" for a path with no resources, like /.
+func pathModuleName(requestPath string) string {
+	segments := pathSegments(requestPath)
+
+	var parts []string
+	for i, segment := range segments {
+		if isPathParam(segment) {
+			continue
+		}
+		if i == len(segments)-1 && i > 0 {
+			afterMember := isPathParam(segments[i-1])
+			if (segment == "new" && !afterMember) || (segment == "edit" && afterMember) {
+				continue
+			}
+		}
+		parts = append(parts, codegen.ToCamelCase(segment))
+	}
+
+	return strings.Join(parts, "::")
+}
+
+// moduleParts splits a module name like Authors::Books into its nested modules.
+func moduleParts(moduleName string) []string {
+	return strings.Split(moduleName, "::")
+}
+
+// inModules wraps body, a class, in a module for each part of moduleName, e.g. module Authors and module Books for
+// Authors::Books, since `module Authors::Books` only works once Authors is defined. Everything's indented by indent
+// spaces, and the body by two more for each module.
+func inModules(moduleName string, indent int, body string) string {
+	parts := moduleParts(moduleName)
+
+	var b strings.Builder
+	for i, part := range parts {
+		b.WriteString(strings.Repeat(" ", indent+2*i) + "module " + codegen.UppercaseFirstCharacter(part) + "\n")
+	}
+	bodyIndent := strings.Repeat(" ", indent+2*len(parts))
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(bodyIndent + line)
+		}
+		b.WriteString("\n")
+	}
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(strings.Repeat(" ", indent+2*i) + "end")
+		if i > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// moduleKey is how Hanami's container keys and routes spell a module, e.g. authors.books for Authors::Books.
+func moduleKey(moduleName string) string {
+	parts := moduleParts(moduleName)
+	for i := range parts {
+		parts[i] = toSnake(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// moduleDir is the directory a module's files go in, relative to actions/ or services/, e.g. authors/books.
+func moduleDir(moduleName string) string {
+	return strings.ReplaceAll(moduleKey(moduleName), ".", "/")
+}
+
+// moduleConstantPrefix flattens a module name into something to prefix constants with, e.g. AuthorsBooks.
+func moduleConstantPrefix(moduleName string) string {
+	parts := moduleParts(moduleName)
+	for i := range parts {
+		parts[i] = codegen.ToCamelCase(parts[i])
+	}
+	return strings.Join(parts, "")
+}
+
+func pathSegments(requestPath string) []string {
+	var segments []string
+	for _, segment := range strings.Split(requestPath, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func isPathParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
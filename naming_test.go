@@ -0,0 +1,323 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_restActionName(t *testing.T) {
+	type args struct {
+		method      string
+		requestPath string
+		moduleName  string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "a GET on a collection is an Index",
+			args: args{method: "GET", requestPath: "/books", moduleName: "Books"},
+			want: "Index",
+		},
+		{
+			name: "a POST on a collection is a Create",
+			args: args{method: "POST", requestPath: "/books", moduleName: "Books"},
+			want: "Create",
+		},
+		{
+			name: "a GET on new is a New",
+			args: args{method: "GET", requestPath: "/books/new", moduleName: "Books"},
+			want: "New",
+		},
+		{
+			name: "a GET on a member is a Show",
+			args: args{method: "GET", requestPath: "/books/{bookId}", moduleName: "Books"},
+			want: "Show",
+		},
+		{
+			name: "a GET on edit is an Edit",
+			args: args{method: "GET", requestPath: "/books/{bookId}/edit", moduleName: "Books"},
+			want: "Edit",
+		},
+		{
+			name: "a PUT or PATCH on a member is an Update",
+			args: args{method: "PATCH", requestPath: "/books/{bookId}", moduleName: "Books"},
+			want: "Update",
+		},
+		{
+			name: "a DELETE on a member is a Destroy",
+			args: args{method: "DELETE", requestPath: "/books/{bookId}", moduleName: "Books"},
+			want: "Destroy",
+		},
+		{
+			name: "a nested collection of the module's own resource is an Index",
+			args: args{method: "GET", requestPath: "/authors/{authorId}/books", moduleName: "Books"},
+			want: "Index",
+		},
+		{
+			name: "anything else after a member is a custom action",
+			args: args{method: "POST", requestPath: "/books/{bookId}/publish", moduleName: "Books"},
+			want: "Publish",
+		},
+		{
+			name: "there's no name for a POST on a member",
+			args: args{method: "POST", requestPath: "/books/{bookId}", moduleName: "Books"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := restActionName(tt.args.method, tt.args.requestPath, tt.args.moduleName)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestNewGeneratorFromConfig_RestNaming(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_rest.yaml"
+	config.OperationNaming = OperationNamingRest
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateRoutesFileTemplateModel()
+	if err != nil {
+		t.Fatalf("error generating routes file: %s\n", err)
+	}
+
+	routes := map[string]string{}
+	for _, route := range model.Slices[0].Routes {
+		routes[route.Method+" "+route.Path] = route.ModuleName + "." + route.OperationName
+	}
+	assert.Equal(t, "books.Index", routes["GET /books"])
+	assert.Equal(t, "books.Show", routes["GET /books/:bookId"])
+	assert.Equal(t, "books.Publish", routes["POST /books/:bookId/publish"])
+	assert.Equal(t, "reviews.Index", routes["GET /books/:bookId/reviews"])
+	// operations with an operationId keep it
+	assert.Equal(t, "reviews.ReplaceReviews", routes["PUT /books/:bookId/reviews"])
+
+	// contracts are still prefixed with the module, to keep them apart
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+	for _, actionTemplateModel := range actionTemplateModels {
+		if actionTemplateModel.ModuleName == "books" && actionTemplateModel.ActionName == "Show" {
+			assert.Equal(t, "BooksShowRequestContract", actionTemplateModel.RequestContract)
+			assert.Equal(t, "BooksShowResponseContract", actionTemplateModel.ResponseContract)
+		}
+	}
+}
+
+func Test_pathModuleName(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestPath string
+		want        string
+	}{
+		{
+			name:        "a collection is its own module",
+			requestPath: "/books",
+			want:        "Books",
+		},
+		{
+			name:        "params are left out",
+			requestPath: "/books/{bookId}",
+			want:        "Books",
+		},
+		{
+			name:        "nested resources nest modules",
+			requestPath: "/authors/{authorId}/books",
+			want:        "Authors::Books",
+		},
+		{
+			name:        "new and edit pages stay in their resource's module",
+			requestPath: "/authors/{authorId}/books/{bookId}/edit",
+			want:        "Authors::Books",
+		},
+		{
+			name:        "segments are camel cased",
+			requestPath: "/book-reviews/new",
+			want:        "BookReviews",
+		},
+		{
+			name:        "there's no module without a resource",
+			requestPath: "/",
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pathModuleName(tt.requestPath))
+		})
+	}
+}
+
+func TestNewGeneratorFromConfig_PathModules(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_nested.yaml"
+	config.ModuleNaming = ModuleNamingPath
+	config.OperationNaming = OperationNamingRest
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+
+	writer := Writer{OutputDir: "gen", Layout: LayoutFlat}
+	actionFilePaths := map[string]string{}
+	for _, actionTemplateModel := range actionTemplateModels {
+		actionFilePaths[actionTemplateModel.ModuleName+"::"+actionTemplateModel.ActionName] = writer.ActionFilePath(actionTemplateModel)
+	}
+	assert.Equal(t, map[string]string{
+		"Authors::Index":          "gen/actions/authors/index.rb",
+		"Authors::Show":           "gen/actions/authors/show.rb",
+		"Authors::Books::Index":   "gen/actions/authors/books/index.rb",
+		"Authors::Books::AddBook": "gen/actions/authors/books/add_book.rb",
+		"Authors::Books::Show":    "gen/actions/authors/books/show.rb",
+		"Authors::Books::Edit":    "gen/actions/authors/books/edit.rb",
+	}, actionFilePaths)
+	assert.Equal(t, "authors.books", moduleKey("Authors::Books"))
+}
+
+func TestNewGeneratorFromConfig_PathModulesWithinSlice(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_nested.yaml"
+	config.Layout = LayoutHanami
+	config.ModuleNaming = ModuleNamingPath
+	config.Slices = map[string]SliceConfig{"Authors": {PathPrefix: "/authors"}}
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	moduleNames := map[string]string{}
+	for _, operationDefinition := range g.OperationDefinitions {
+		assert.Equal(t, "Authors", operationDefinition.SliceName)
+		moduleNames[operationDefinition.Path] = operationDefinition.ModuleName
+	}
+	// the slice's root has nothing left to name a module after once its prefix is taken off
+	assert.Equal(t, "Authors", moduleNames["/authors"])
+	assert.Equal(t, "Books", moduleNames["/authors/{authorId}/books"])
+}
+
+func moduleNamesByOperationId(t *testing.T, config *Config) map[string]string {
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	moduleNames := map[string]string{}
+	for _, operationDefinition := range g.OperationDefinitions {
+		moduleNames[operationDefinition.OperationId] = operationDefinition.ModuleName
+	}
+	return moduleNames
+}
+
+func TestNewGeneratorFromConfig_TagPolicyFirst(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_multiple_tags.yaml"
+
+	assert.Equal(t, map[string]string{
+		"ListAuthors":       "beta",
+		"ListBooks":         "books",
+		"ListFeaturedBooks": "Catalogue::Featured",
+		"ListReviews":       "reviews",
+	}, moduleNamesByOperationId(t, config))
+}
+
+func TestNewGeneratorFromConfig_TagPolicyPriority(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_multiple_tags.yaml"
+	config.TagPolicy = TagPolicyPriority
+	config.TagPriority = []string{"books", "authors"}
+
+	assert.Equal(t, map[string]string{
+		"ListAuthors":       "authors",
+		"ListBooks":         "books",
+		"ListFeaturedBooks": "Catalogue::Featured",
+		"ListReviews":       "reviews",
+	}, moduleNamesByOperationId(t, config))
+}
+
+func TestNewGeneratorFromConfig_TagPolicyError(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_multiple_tags.yaml"
+	config.TagPolicy = TagPolicyError
+
+	_, err := NewGeneratorFromConfig(config)
+	assert.ErrorIs(t, err, ErrAmbiguousTags)
+	assert.ErrorContains(t, err, "ListAuthors")
+}
+
+func TestNewGeneratorFromConfig_SkipAnyTag(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_multiple_tags.yaml"
+	config.TagPolicy = TagPolicyError
+	config.Tags = map[string]TagConfig{"beta": {Skip: true}}
+
+	assert.Equal(t, map[string]string{"ListReviews": "reviews"}, moduleNamesByOperationId(t, config))
+}
+
+func TestNewGeneratorFromConfig_SkipAnyTag_FirstPolicy(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_multiple_tags.yaml"
+	config.Tags = map[string]TagConfig{"beta": {Skip: true}}
+
+	// ListBooks is tagged [books, beta], so it's skipped even though its module would be books
+	assert.Equal(t, map[string]string{"ListReviews": "reviews"}, moduleNamesByOperationId(t, config))
+}
+
+func TestWriter_NestedModules(t *testing.T) {
+	w, err := NewWriter("gen", "TestApp", "", LayoutFlat)
+	assert.NoError(t, err)
+
+	// without an autoloader, module Authors::Books would fail on the missing Authors
+	action, err := w.ExecuteActionFileTemplate(ActionTemplateModel{SliceName: "API", ActionName: "Index", ModuleName: "Authors::Books", BaseActionClass: "API::Action"})
+	assert.NoError(t, err)
+	assert.Contains(t, action.String(), `module API
+  module Actions
+    module Authors
+      module Books
+        class Index < API::Action
+`)
+	assert.Contains(t, action.String(), `
+          end
+        end
+      end
+    end
+  end
+end
+`)
+
+	service, err := w.ExecuteServiceFileTemplate(ServiceTemplateModel{SliceName: "API", ServiceName: "Index", ModuleName: "Authors::Books"})
+	assert.NoError(t, err)
+	assert.Equal(t, `require "dry/monads"
+
+module API
+  module Services
+    module Authors
+      module Books
+        class Index
+          include Dry::Monads[:result]
+          def call(params)
+            Success({})
+          end
+        end
+      end
+    end
+  end
+end
+`, service.String())
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// isOpenAPI31 reports whether data, YAML or JSON, is an OpenAPI 3.1 document.
+func isOpenAPI31(data []byte) bool {
+	var header struct {
+		OpenAPI string `json:"openapi"`
+	}
+	err := yaml.Unmarshal(data, &header)
+	return err == nil && strings.HasPrefix(header.OpenAPI, "3.1")
+}
+
+// openAPI31Downgrade rewrites OpenAPI 3.1 documents into OpenAPI 3.0 ones as they're read, since kin-openapi
+// only understands 3.0. The JSON Schema 2020-12 keywords 3.1 allows in schemas are mapped onto their nearest
+// 3.0 equivalent:
+//
+//   - type arrays with "null" become nullable, e.g. [string, "null"] is type: string, nullable: true
+//   - const becomes a single value enum
+//   - prefixItems where every item has the same schema become items
+//   - $defs are moved into components/schemas, and refs to them rewritten
+//   - examples becomes example, using the first one
+//   - numeric exclusiveMinimum and exclusiveMaximum become minimum and maximum with the 3.0 boolean flags
+//
+// Anything that changes the shape of the data without a 3.0 equivalent, like a type array with several
+// non-null types, is an error. Keywords that only tighten validation, like unevaluatedProperties, are ignored
+// with a warning, and so are webhooks, since the generator has nothing to generate for requests the app sends.
+type openAPI31Downgrade struct {
+	warnings []Diagnostic
+	errs     []Diagnostic
+	// seen holds the files already downgraded, which the loader can read more than once
+	seen map[string]bool
+}
+
+func newOpenAPI31Downgrade() *openAPI31Downgrade {
+	return &openAPI31Downgrade{seen: map[string]bool{}}
+}
+
+// readFile is a openapi3.Loader ReadFromURIFunc that downgrades each file it reads.
+func (d *openAPI31Downgrade) readFile(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	data, err := readLocalFile(loader, location)
+	if err != nil {
+		return nil, err
+	}
+
+	report := !d.seen[location.Path]
+	d.seen[location.Path] = true
+
+	file := &openAPI31File{path: location.Path}
+	data, err = file.downgrade(data)
+	if err != nil {
+		return nil, fmt.Errorf("error downgrading OpenAPI 3.1 spec %s: %w", location.Path, err)
+	}
+
+	if report {
+		d.warnings = append(d.warnings, file.warnings...)
+		d.errs = append(d.errs, file.errs...)
+	}
+
+	return data, nil
+}
+
+func (d *openAPI31Downgrade) err() error {
+	if len(d.errs) == 0 {
+		return nil
+	}
+
+	return &DiagnosticsError{Summary: "unsupported OpenAPI 3.1 constructs", Diagnostics: d.errs}
+}
+
+// openAPI31File is the downgrade of a single file, the spec itself or one it refs.
+type openAPI31File struct {
+	path     string
+	warnings []Diagnostic
+	errs     []Diagnostic
+	defs     []openAPI31Def
+}
+
+// openAPI31Def is a schema in $defs, to be moved into components/schemas.
+type openAPI31Def struct {
+	name    string
+	pointer string
+	parent  map[string]interface{}
+	schema  interface{}
+}
+
+// openAPI31NamedMaps are the keywords whose values map names to objects, rather than being objects
+// themselves, so their keys aren't keywords, e.g. a property called type.
+var openAPI31NamedMaps = map[string]bool{
+	"properties": true, "patternProperties": true, "$defs": true, "definitions": true, "dependentSchemas": true,
+	"schemas": true, "parameters": true, "responses": true, "requestBodies": true, "headers": true, "examples": true,
+	"links": true, "callbacks": true, "securitySchemes": true, "pathItems": true, "paths": true, "webhooks": true,
+	"content": true, "encoding": true, "variables": true, "mapping": true,
+}
+
+// openAPI31Literals are the keywords whose values are data rather than spec, so are left alone.
+var openAPI31Literals = map[string]bool{
+	"example": true, "default": true, "enum": true, "const": true, "value": true,
+}
+
+// openAPI31IgnoredKeywords are the JSON Schema keywords without a 3.0 equivalent that can be ignored, at the cost
+// of the generated contracts being looser than the spec.
+var openAPI31IgnoredKeywords = []string{
+	"$anchor", "$dynamicAnchor", "$dynamicRef", "contains", "contentSchema", "dependentRequired",
+	"dependentSchemas", "else", "if", "maxContains", "minContains", "patternProperties", "propertyNames", "then",
+	"unevaluatedItems", "unevaluatedProperties",
+}
+
+func (f *openAPI31File) downgrade(data []byte) ([]byte, error) {
+	var doc interface{}
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+
+	if _, ok := root["openapi"]; ok {
+		f.downgradeRoot(root)
+	}
+
+	f.walk("", root, false)
+	f.moveDefs(root)
+
+	return json.Marshal(root)
+}
+
+func (f *openAPI31File) warn(pointer string, format string, args ...any) {
+	f.warnings = append(f.warnings, Diagnostic{f.path, pointer, fmt.Sprintf(format, args...)})
+}
+
+func (f *openAPI31File) fail(pointer string, format string, args ...any) {
+	f.errs = append(f.errs, Diagnostic{f.path, pointer, fmt.Sprintf(format, args...)})
+}
+
+func (f *openAPI31File) downgradeRoot(root map[string]interface{}) {
+	root["openapi"] = "3.0.3"
+	delete(root, "jsonSchemaDialect")
+
+	if webhooks, ok := root["webhooks"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(webhooks) {
+			f.warn(jsonPointer("webhooks", name), "webhooks aren't generated, they describe requests the app sends rather than receives")
+		}
+	}
+	delete(root, "webhooks")
+
+	// paths is optional in 3.1, e.g. for specs that only have webhooks
+	if _, ok := root["paths"]; !ok {
+		root["paths"] = map[string]interface{}{}
+	}
+
+	if components, ok := root["components"].(map[string]interface{}); ok {
+		if _, ok := components["pathItems"]; ok {
+			f.warn(jsonPointer("components", "pathItems"), "components/pathItems aren't supported and are ignored")
+			delete(components, "pathItems")
+		}
+	}
+}
+
+// walk downgrades value, at pointer in the file, and everything in it. namedMap says value is one of
+// openAPI31NamedMaps.
+func (f *openAPI31File) walk(pointer string, value interface{}, namedMap bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if namedMap {
+			for _, key := range sortedKeys(value) {
+				f.walk(pointer+jsonPointer(key), value[key], false)
+			}
+			return
+		}
+
+		f.downgradeSchemaKeywords(pointer, value)
+
+		for _, key := range sortedKeys(value) {
+			if strings.HasPrefix(key, "x-") || openAPI31Literals[key] {
+				continue
+			}
+			f.walk(pointer+jsonPointer(key), value[key], openAPI31NamedMaps[key])
+		}
+	case []interface{}:
+		for i, item := range value {
+			f.walk(pointer+jsonPointer(strconv.Itoa(i)), item, false)
+		}
+	}
+}
+
+// downgradeSchemaKeywords rewrites the 3.1 only keywords in m, if it's a schema. None of them mean anything
+// elsewhere in a spec, or have different types there, so there's no need to track which objects are schemas.
+func (f *openAPI31File) downgradeSchemaKeywords(pointer string, m map[string]interface{}) {
+	if types, ok := m["type"].([]interface{}); ok {
+		var nonNullTypes []string
+		nullable := false
+		for _, t := range types {
+			if t == "null" {
+				nullable = true
+			} else {
+				nonNullTypes = append(nonNullTypes, fmt.Sprint(t))
+			}
+		}
+
+		switch len(nonNullTypes) {
+		case 0:
+			f.fail(pointer+jsonPointer("type"), `type "null" on its own isn't supported`)
+		case 1:
+			m["type"] = nonNullTypes[0]
+			if nullable {
+				m["nullable"] = true
+			}
+		default:
+			f.fail(pointer+jsonPointer("type"), "type %s has more than one non-null type, which isn't supported, use a single type", strings.Join(nonNullTypes, ", "))
+		}
+	}
+
+	if constValue, ok := m["const"]; ok {
+		m["enum"] = []interface{}{constValue}
+		delete(m, "const")
+	}
+
+	if examples, ok := m["examples"].([]interface{}); ok {
+		if _, ok := m["example"]; !ok && len(examples) > 0 {
+			m["example"] = examples[0]
+		}
+		delete(m, "examples")
+	}
+
+	if prefixItems, ok := m["prefixItems"].([]interface{}); ok {
+		f.downgradePrefixItems(pointer, m, prefixItems)
+	}
+
+	for _, bound := range []string{"Minimum", "Maximum"} {
+		exclusive := "exclusive" + bound
+		if value, ok := m[exclusive].(float64); ok {
+			m[strings.ToLower(bound)] = value
+			m[exclusive] = true
+		}
+	}
+
+	if defs, ok := m["$defs"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(defs) {
+			f.defs = append(f.defs, openAPI31Def{
+				name:    name,
+				pointer: pointer + jsonPointer("$defs", name),
+				parent:  m,
+				schema:  defs[name],
+			})
+		}
+	}
+
+	for _, keyword := range openAPI31IgnoredKeywords {
+		if _, ok := m[keyword]; ok {
+			f.warn(pointer+jsonPointer(keyword), "%s isn't supported and is ignored", keyword)
+		}
+	}
+}
+
+// downgradePrefixItems turns prefixItems into items, which only works if every item has the same schema.
+// Tuples of different types can't be described in 3.0, or validated by dry-schema's array.
+func (f *openAPI31File) downgradePrefixItems(pointer string, m map[string]interface{}, prefixItems []interface{}) {
+	delete(m, "prefixItems")
+	if len(prefixItems) == 0 {
+		return
+	}
+
+	for _, item := range prefixItems[1:] {
+		if !sameJson(item, prefixItems[0]) {
+			f.fail(pointer+jsonPointer("prefixItems"), "prefixItems with different schemas for each item aren't supported, use items")
+			return
+		}
+	}
+
+	switch items := m["items"].(type) {
+	case nil:
+		m["items"] = prefixItems[0]
+	case bool:
+		// items: false means no more items than prefixItems
+		m["items"] = prefixItems[0]
+		if !items {
+			m["maxItems"] = len(prefixItems)
+		}
+	default:
+		if !sameJson(items, prefixItems[0]) {
+			f.fail(pointer+jsonPointer("prefixItems"), "prefixItems with a different schema to items aren't supported, use items")
+		}
+	}
+}
+
+// moveDefs moves every $defs schema into components/schemas, under its name in $defs, and points refs to it
+// at its new home.
+func (f *openAPI31File) moveDefs(root map[string]interface{}) {
+	if len(f.defs) == 0 {
+		return
+	}
+
+	components, ok := root["components"].(map[string]interface{})
+	if !ok {
+		components = map[string]interface{}{}
+		root["components"] = components
+	}
+	schemas, ok := components["schemas"].(map[string]interface{})
+	if !ok {
+		schemas = map[string]interface{}{}
+		components["schemas"] = schemas
+	}
+
+	refs := map[string]string{}
+	for _, def := range f.defs {
+		if existing, ok := schemas[def.name]; ok && !sameJson(existing, def.schema) {
+			f.fail(def.pointer, "can't move $defs schema %s into components/schemas, which already has a different schema called that", def.name)
+			continue
+		}
+
+		schemas[def.name] = def.schema
+		refs["#"+def.pointer] = componentSchemasRefPrefix + def.name
+	}
+
+	for _, def := range f.defs {
+		delete(def.parent, "$defs")
+	}
+
+	rewriteRefs(root, refs)
+}
+
+// rewriteRefs replaces every $ref in value that points at or into one of the keys of refs.
+func rewriteRefs(value interface{}, refs map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			// the longest match wins, for $defs inside $defs
+			matched := ""
+			for oldRef := range refs {
+				if (ref == oldRef || strings.HasPrefix(ref, oldRef+"/")) && len(oldRef) > len(matched) {
+					matched = oldRef
+				}
+			}
+			if matched != "" {
+				value["$ref"] = refs[matched] + strings.TrimPrefix(ref, matched)
+			}
+		}
+		for _, child := range value {
+			rewriteRefs(child, refs)
+		}
+	case []interface{}:
+		for _, item := range value {
+			rewriteRefs(item, refs)
+		}
+	}
+}
+
+func sameJson(a interface{}, b interface{}) bool {
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJson) == string(bJson)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadSwagger_OpenAPI31(t *testing.T) {
+	swagger, warnings, err := loadSwagger("fixtures/openapi31/spec.yaml")
+	if err != nil {
+		t.Fatalf("error loading spec: %s\n", err)
+	}
+
+	assert.Equal(t, "3.0.3", swagger.OpenAPI)
+	assert.Equal(t, []Diagnostic{
+		{"fixtures/openapi31/spec.yaml", "/webhooks/newPet", "webhooks aren't generated, they describe requests the app sends rather than receives"},
+		{"fixtures/openapi31/spec.yaml", "/components/schemas/Pet/unevaluatedProperties", "unevaluatedProperties isn't supported and is ignored"},
+	}, warnings)
+
+	pet := swagger.Components.Schemas["Pet"].Value
+	assert.Equal(t, "string", pet.Properties["nickname"].Value.Type)
+	assert.True(t, pet.Properties["nickname"].Value.Nullable)
+	assert.Equal(t, []interface{}{"dog"}, pet.Properties["kind"].Value.Enum)
+	assert.Equal(t, "Rex", pet.Properties["name"].Value.Example)
+	assert.Equal(t, 0.0, *pet.Properties["age"].Value.Min)
+	assert.True(t, pet.Properties["age"].Value.ExclusiveMin)
+	assert.Equal(t, "number", pet.Properties["position"].Value.Items.Value.Type)
+	assert.Equal(t, uint64(2), *pet.Properties["position"].Value.MaxItems)
+	assert.Equal(t, "#/components/schemas/Owner", pet.Properties["owner"].Ref)
+	assert.Contains(t, swagger.Components.Schemas, "Owner")
+}
+
+func TestLoadSwagger_OpenAPI31Unsupported(t *testing.T) {
+	_, _, err := loadSwagger("fixtures/openapi31/unsupported.yaml")
+	assert.ErrorContains(t, err, "fixtures/openapi31/unsupported.yaml#/components/schemas/Thing/properties/id/type: type string, integer has more than one non-null type")
+	assert.ErrorContains(t, err, "fixtures/openapi31/unsupported.yaml#/components/schemas/Thing/properties/point/prefixItems: prefixItems with different schemas for each item aren't supported")
+}
+
+func TestGenerator_OpenAPI31(t *testing.T) {
+	g, err := NewGenerator("fixtures/openapi31/spec.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	model, err := g.GenerateSchemasFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating schemas file: %s\n", err)
+	}
+
+	pet := model.Schemas[1]
+	assert.Equal(t, "Pet", pet.SchemaName)
+	assert.Equal(t, AttributeDefinition{
+		AttributeName: "kind",
+		Key:           "kind",
+		AttributeType: ":string",
+		Verb:          "value",
+		Required:      true,
+		Predicates:    []string{`eql?: "dog"`},
+	}, pet.Attributes[1])
+	assert.Equal(t, AttributeDefinition{
+		AttributeName: "nickname",
+		Key:           "nickname",
+		AttributeType: ":string",
+		Verb:          "maybe",
+	}, pet.Attributes[3])
+	assert.Equal(t, AttributeDefinition{
+		AttributeName: "status",
+		Key:           "status",
+		AttributeType: ":string",
+		Verb:          "value",
+		Predicates:    []string{`included_in?: ["available", "sold"]`},
+	}, pet.Attributes[6])
+}
+
+func Test_rubyLiteral(t *testing.T) {
+	assert.Equal(t, `"a \"quoted\" \#{string}"`, rubyLiteral(`a "quoted" #{string}`))
+	assert.Equal(t, "1.5", rubyLiteral(1.5))
+	assert.Equal(t, "3", rubyLiteral(float64(3)))
+	assert.Equal(t, "true", rubyLiteral(true))
+	assert.Equal(t, "nil", rubyLiteral(nil))
+	assert.Equal(t, `{ "name" => "Rex", "tags" => ["good", 1] }`, rubyLiteral(map[string]any{"tags": []any{"good", float64(1)}, "name": "Rex"}))
+	assert.Equal(t, "{}", rubyLiteral(map[string]any{}))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"strings"
+)
+
+// PersistenceTemplateModel is the ROM relation, repository and entity struct for a component schema that maps to a
+// database table, with an optional migration draft creating the table.
+type PersistenceTemplateModel struct {
+	AppName string
+	// SchemaName names the entity struct and repository, e.g. Pet and PetRepo, and TableName the table and
+	// relation, e.g. pets and Pets.
+	SchemaName string
+	TableName  string
+	// RelationBaseClass, RepoBaseClass and StructBaseClass are what the generated classes inherit from: the
+	// app's DB classes in a Hanami app, or ROM's own in the flat layout.
+	RelationBaseClass string
+	RepoBaseClass     string
+	StructBaseClass   string
+	// Migration is set if a migration should be drafted, with Columns as the lines of its create_table block,
+	// e.g. `column :name, String, null: false`.
+	Migration bool
+	Columns   []string
+	// Extensions are the schema's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+// persistedSchemaNames lists the component schemas that map to database tables, marked with ExtensionPersist or
+// listed in persist, in the order their tables need creating so foreign keys have something to point at.
+func persistedSchemaNames(schemas openapi3.Schemas, persist []string) ([]string, error) {
+	listed := map[string]bool{}
+	var errs []error
+	for _, name := range persist {
+		if schemas[name] == nil {
+			errs = append(errs, fmt.Errorf("persist: there's no component schema called %q", name))
+		}
+		listed[name] = true
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var names []string
+	for _, name := range schemaDefinitionOrder(schemas) {
+		schemaExtensions, _ := readSchemaExtensions(schemas[name].Value.Extensions)
+		if !schemaExtensions.Skip && (listed[name] || schemaExtensions.Persist) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// GeneratePersistenceTemplateModels generates the persistence classes of every persisted schema. Schemas are
+// shared by every slice, so these go in the app rather than a slice.
+func (g Generator) GeneratePersistenceTemplateModels() ([]PersistenceTemplateModel, error) {
+	tableNames := map[string]string{}
+	for _, schemaName := range g.PersistedSchemas {
+		tableNames[schemaName] = tableName(schemaName)
+	}
+
+	var persistenceTemplateModels []PersistenceTemplateModel
+	for _, schemaName := range g.PersistedSchemas {
+		persistenceTemplateModel := PersistenceTemplateModel{
+			AppName:           g.AppName,
+			SchemaName:        schemaName,
+			TableName:         tableNames[schemaName],
+			RelationBaseClass: "ROM::Relation[:sql]",
+			RepoBaseClass:     "ROM::Repository::Root",
+			StructBaseClass:   "ROM::Struct",
+			Migration:         g.Migrations,
+			Extensions:        decodedExtensions(g.Schemas[schemaName].Value.Extensions),
+		}
+		if g.Layout == LayoutHanami {
+			persistenceTemplateModel.RelationBaseClass = g.AppName + "::DB::Relation"
+			persistenceTemplateModel.RepoBaseClass = g.AppName + "::DB::Repo"
+			persistenceTemplateModel.StructBaseClass = g.AppName + "::DB::Struct"
+		}
+
+		if g.Migrations {
+			persistenceTemplateModel.Columns = g.columnDefinitions(g.Schemas[schemaName], tableNames)
+		}
+
+		persistenceTemplateModels = append(persistenceTemplateModels, persistenceTemplateModel)
+	}
+
+	return persistenceTemplateModels, nil
+}
+
+// columnDefinitions drafts a table's columns from its schema's attributes. They go by the dry-types types
+// generateAttributeDefinition maps properties to, so typeMappings carry through to the database, and properties
+// that refer to other persisted schemas become foreign keys.
+func (g Generator) columnDefinitions(schemaRef *openapi3.SchemaRef, tableNames map[string]string) []string {
+	var columns []string
+	hasPrimaryKey := false
+	for _, attributeDefinition := range g.generateAttributeDefinitions(schemaRef) {
+		name := toSnake(attributeDefinition.AttributeName)
+		property := schemaRef.Value.Properties[attributeDefinition.AttributeName].Value
+		referencedTable := ""
+		if schemaName, ok := strings.CutPrefix(attributeDefinition.AttributeType, "Schemas::"); ok {
+			referencedTable = tableNames[schemaName]
+		}
+
+		var column string
+		switch {
+		case attributeDefinition.Verb == "array" && referencedTable != "":
+			columns = append(columns, fmt.Sprintf("# %s are in the %s table, which needs a foreign key back to this one", name, referencedTable))
+			continue
+		case referencedTable != "":
+			column = fmt.Sprintf("foreign_key :%s_id, :%s", name, referencedTable)
+		case name == "id" && attributeDefinition.AttributeType == ":integer":
+			columns = append(columns, "primary_key :id")
+			hasPrimaryKey = true
+			continue
+		case name == "id":
+			column = fmt.Sprintf("column :id, %s, primary_key: true", columnType(attributeDefinition, property))
+			hasPrimaryKey = true
+		default:
+			column = fmt.Sprintf("column :%s, %s", name, columnType(attributeDefinition, property))
+		}
+
+		if attributeDefinition.Required {
+			column += ", null: false"
+		}
+		if property.Default != nil && attributeDefinition.Verb != "array" {
+			column += ", default: " + rubyLiteral(property.Default)
+		}
+		if comment := columnTypeComment(attributeDefinition); comment != "" {
+			column += " # " + comment
+		}
+		columns = append(columns, column)
+	}
+
+	if !hasPrimaryKey {
+		columns = append([]string{"primary_key :id"}, columns...)
+	}
+
+	return columns
+}
+
+// columnTypes maps dry-types types onto the Sequel column types migrations use.
+var columnTypes = map[string]string{
+	":string":    "String",
+	":integer":   "Integer",
+	":float":     "Float",
+	":decimal":   "BigDecimal",
+	":bool":      "TrueClass",
+	":date":      "Date",
+	":date_time": "DateTime",
+	":time":      "DateTime",
+	":uuid_v4?":  ":uuid",
+}
+
+// columnType is the column type for an attribute. Arrays, objects and schemas that aren't tables of their own are
+// stored as JSON, and strings whose format says more than their type mapping are given the column type it says.
+func columnType(attributeDefinition AttributeDefinition, property *openapi3.Schema) string {
+	if attributeDefinition.Verb == "array" || attributeDefinition.AttributeType == ":hash" || strings.HasPrefix(attributeDefinition.AttributeType, "Schemas::") {
+		return ":jsonb"
+	}
+
+	if attributeDefinition.AttributeType == ":string" {
+		switch property.Format {
+		case "date":
+			return "Date"
+		case "date-time":
+			return "DateTime"
+		case "uuid":
+			return ":uuid"
+		}
+	}
+
+	if columnType, ok := columnTypes[attributeDefinition.AttributeType]; ok {
+		return columnType
+	}
+
+	return "String"
+}
+
+// columnTypeComment points out columns whose type was a guess, because their Ruby type isn't one columnTypes knows.
+func columnTypeComment(attributeDefinition AttributeDefinition) string {
+	if _, ok := columnTypes[attributeDefinition.AttributeType]; ok || attributeDefinition.Verb == "array" {
+		return ""
+	}
+	if attributeDefinition.AttributeType == ":hash" || strings.HasPrefix(attributeDefinition.AttributeType, "Schemas::") {
+		return ""
+	}
+
+	return "TODO: pick a column type for " + attributeDefinition.AttributeType
+}
+
+// tableName is the snake cased plural of a schema name, e.g. book_reviews for BookReview, going by the
+// usual English rules rather than every exception to them.
+func tableName(schemaName string) string {
+	name := toSnake(schemaName)
+	switch {
+	case len(name) > 1 && strings.HasSuffix(name, "y") && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerator_GeneratePersistenceTemplateModels(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_persistence.yaml"
+	config.Persist = []string{"Book"}
+	config.Migrations = true
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+	assert.Equal(t, []string{"Book", "Author"}, g.PersistedSchemas)
+
+	persistenceTemplateModels, err := g.GeneratePersistenceTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating persistence template models: %s\n", err)
+	}
+	book, author := persistenceTemplateModels[0], persistenceTemplateModels[1]
+
+	assert.Equal(t, "books", book.TableName)
+	assert.Equal(t, "ROM::Relation[:sql]", book.RelationBaseClass)
+	assert.Equal(t, []string{
+		"foreign_key :author_id, :authors",
+		"primary_key :id",
+		"column :price, String # TODO: pick a column type for Types::Money",
+		"column :published_at, DateTime",
+		`column :status, String, default: "draft"`,
+		"column :tags, :jsonb",
+		"column :title, String, null: false",
+	}, book.Columns)
+
+	assert.Equal(t, []string{
+		"# books are in the books table, which needs a foreign key back to this one",
+		"column :id, :uuid, primary_key: true, null: false",
+		"column :name, String, null: false",
+	}, author.Columns)
+}
+
+func TestNewGeneratorFromConfig_PersistUnknownSchema(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec_persistence.yaml"
+	config.Persist = []string{"Publisher"}
+
+	_, err := NewGeneratorFromConfig(config)
+	assert.ErrorContains(t, err, `persist: there's no component schema called "Publisher"`)
+}
+
+func Test_tableName(t *testing.T) {
+	assert.Equal(t, "book_reviews", tableName("BookReview"))
+	assert.Equal(t, "categories", tableName("Category"))
+	assert.Equal(t, "surveys", tableName("Survey"))
+	assert.Equal(t, "addresses", tableName("Address"))
+}
+
+func TestWriter_HanamiLayout_Persistence(t *testing.T) {
+	outputDir := t.TempDir()
+	now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	// Author's table has been created already
+	migrationsDir := filepath.Join(outputDir, "config", "db", "migrate")
+	assert.NoError(t, os.MkdirAll(migrationsDir, os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(migrationsDir, "20230101000000_create_authors.rb"), []byte("# ours"), 0644))
+
+	generateInto(t, &Config{
+		Input:      "fixtures/test_spec_persistence.yaml",
+		AppName:    "Bookshop",
+		SliceName:  "Catalogue",
+		OutputDir:  outputDir,
+		Generate:   []string{"persistence"},
+		Persist:    []string{"Book"},
+		Migrations: true,
+	}, LayoutHanami)
+
+	for _, filePath := range []string{
+		"app/relations/books.rb",
+		"app/repos/book_repo.rb",
+		"app/structs/book.rb",
+		"app/relations/authors.rb",
+		"config/db/migrate/20240501120000_create_books.rb",
+	} {
+		assert.FileExists(t, filepath.Join(outputDir, filePath))
+	}
+	assert.NoFileExists(t, filepath.Join(migrationsDir, "20240501120001_create_authors.rb"))
+
+	repo, err := os.ReadFile(filepath.Join(outputDir, "app/repos/book_repo.rb"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(repo), "class BookRepo < Bookshop::DB::Repo")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RequestSpecTemplateModel is an RSpec request spec for an operation, which calls it through the app's routes with
+// the spec's examples, see examples.go.
+type RequestSpecTemplateModel struct {
+	AppName    string
+	SliceName  string
+	ModuleName string
+	ActionName string
+	// Method and Path are the operation's, as written in the spec, e.g. GET /books/{bookId}.
+	Method string
+	Path   string
+	// RequestPath is a Ruby string of the URL to request, with the slice's mount and example path params filled in.
+	RequestPath string
+	// Query and Body are Ruby literals of the example query params and JSON body. Body is empty for operations
+	// without one.
+	Query string
+	Body  string
+	// ResponseContract is the name of the operation's response contract in contracts.rb.
+	ResponseContract string
+	// RequiredParams are the params the request contract rejects a request without, with a 422.
+	RequiredParams []RequiredParamTemplateModel
+	// Extensions are the operation's vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+type RequiredParamTemplateModel struct {
+	// Name is the param's name as sent, i.e. as written in the spec.
+	Name string
+	// InBody is set for properties of the JSON body, rather than query params.
+	InBody bool
+}
+
+// GenerateRequestSpecTemplateModels generates a request spec for every operation.
+func (g Generator) GenerateRequestSpecTemplateModels() ([]RequestSpecTemplateModel, error) {
+	var requestSpecTemplateModels []RequestSpecTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+			requestSpecTemplateModels = append(requestSpecTemplateModels, g.requestSpecTemplateModel(operationDefinition))
+		}
+	}
+
+	return requestSpecTemplateModels, nil
+}
+
+func (g Generator) requestSpecTemplateModel(operationDefinition OperationDefinition) RequestSpecTemplateModel {
+	query := map[string]any{}
+	for _, param := range operationDefinition.QueryParams {
+		if extensions, _ := readSchemaExtensions(param.Spec.Extensions); !extensions.Skip {
+			query[param.ParamName] = parameterExample(param.Spec)
+		}
+	}
+
+	var requiredParams []RequiredParamTemplateModel
+	// the request contract only has the operation's own params, not ones shared by the path
+	for _, param := range operationDefinition.Spec.Parameters {
+		extensions, _ := readSchemaExtensions(param.Value.Extensions)
+		if param.Value.In == "query" && param.Value.Required && !extensions.Skip {
+			requiredParams = append(requiredParams, RequiredParamTemplateModel{Name: param.Value.Name})
+		}
+	}
+
+	var body string
+	if operationDefinition.RequestBodySchema != nil {
+		body = rubyLiteral(mediaTypeExample(operationDefinition.Spec.RequestBody.Value.GetMediaType(MediaTypeJson)))
+		for _, attributeDefinition := range g.generateAttributeDefinitions(operationDefinition.RequestBodySchema) {
+			if attributeDefinition.Required {
+				requiredParams = append(requiredParams, RequiredParamTemplateModel{Name: attributeDefinition.AttributeName, InBody: true})
+			}
+		}
+	}
+
+	return RequestSpecTemplateModel{
+		AppName:          g.AppName,
+		SliceName:        operationDefinition.SliceName,
+		ModuleName:       operationDefinition.ModuleName,
+		ActionName:       operationDefinition.ActionName,
+		Method:           operationDefinition.Method,
+		Path:             operationDefinition.Path,
+		RequestPath:      rubyLiteral(g.exampleRequestPath(operationDefinition)),
+		Query:            rubyLiteral(query),
+		Body:             body,
+		ResponseContract: responseContractName(operationDefinition),
+		RequiredParams:   requiredParams,
+		Extensions:       decodedExtensions(operationDefinition.Spec.Extensions),
+	}
+}
+
+// exampleRequestPath is the URL an operation is routed from, with example values for its path params.
+func (g Generator) exampleRequestPath(operationDefinition OperationDefinition) string {
+	path := g.routePath(operationDefinition)
+	for _, param := range operationDefinition.PathParams {
+		path = strings.ReplaceAll(path, "{"+param.ParamName+"}", pathParamValue(parameterExample(param.Spec)))
+	}
+
+	if path == "/" {
+		return g.sliceMount(operationDefinition.SliceName)
+	}
+
+	return g.sliceMount(operationDefinition.SliceName) + path
+}
+
+// pathParamValue writes an example value into a path, e.g. 42 rather than the 42.0 it was decoded as.
+func pathParamValue(value any) string {
+	if s, ok := value.(string); ok {
+		return url.PathEscape(s)
+	}
+
+	return url.PathEscape(rubyLiteral(value))
+}
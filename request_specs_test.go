@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerator_GenerateRequestSpecTemplateModels(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_examples.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	requestSpecTemplateModels, err := g.GenerateRequestSpecTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating request spec template models: %s\n", err)
+	}
+	assert.Len(t, requestSpecTemplateModels, 2)
+
+	// the path's own params are filled in too, and skipped params are left out
+	listReviews := requestSpecTemplateModels[0]
+	assert.Equal(t, "GET", listReviews.Method)
+	assert.Equal(t, "/books/{bookId}/reviews", listReviews.Path)
+	assert.Equal(t, `"/api/books/dune/reviews"`, listReviews.RequestPath)
+	assert.Equal(t, `{ "page" => 1, "sort" => "newest" }`, listReviews.Query)
+	assert.Empty(t, listReviews.Body)
+	assert.Equal(t, "ListReviewsResponseContract", listReviews.ResponseContract)
+	assert.Equal(t, []RequiredParamTemplateModel{{Name: "page"}}, listReviews.RequiredParams)
+
+	addReview := requestSpecTemplateModels[1]
+	assert.Equal(t, `{}`, addReview.Query)
+	assert.Equal(t, `{ "rating" => 5, "text" => "A classic" }`, addReview.Body)
+	assert.Equal(t, []RequiredParamTemplateModel{{Name: "rating", InBody: true}, {Name: "text", InBody: true}}, addReview.RequiredParams)
+}
+
+func TestWriter_RequestSpecFilePath(t *testing.T) {
+	model := RequestSpecTemplateModel{SliceName: "API", ModuleName: "Authors::Books", ActionName: "AddBook"}
+
+	assert.Equal(t, "gen/spec/requests/authors/books/add_book_spec.rb", Writer{OutputDir: "gen", Layout: LayoutFlat}.RequestSpecFilePath(model))
+	assert.Equal(t, "gen/spec/slices/api/requests/authors/books/add_book_spec.rb", Writer{OutputDir: "gen", Layout: LayoutHanami}.RequestSpecFilePath(model))
+}
@@ -0,0 +1,105 @@
+package main
+
+// The parts of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) lint output
+// needs. Specs don't keep line numbers once parsed, so results are located by file, with the JSON pointer as a
+// logical location.
+
+const sarifVersion = "2.1.0"
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func newSarifLog(problems []LintProblem) sarifLog {
+	rules := make([]sarifRule, len(LintRules))
+	for i, rule := range LintRules {
+		rules[i] = sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifRuleConfiguration{Level: string(rule.Severity)},
+		}
+	}
+
+	results := make([]sarifResult, len(problems))
+	for i, problem := range problems {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: problem.File}},
+		}
+		if problem.Pointer != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: problem.Pointer}}
+		}
+
+		results[i] = sarifResult{
+			RuleID:    problem.Rule,
+			Level:     string(problem.Severity),
+			Message:   sarifMessage{Text: problem.Message},
+			Locations: []sarifLocation{location},
+		}
+	}
+
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: sarifDriver{Name: "oapi-hanami-codegen", Rules: rules}},
+				Results: results,
+			},
+		},
+	}
+}
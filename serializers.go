@@ -0,0 +1,181 @@
+package main
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"strings"
+)
+
+// DecimalFormat is how serializers write BigDecimals, which JSON has no type of its own for.
+type DecimalFormat string
+
+const (
+	// DecimalFormatNumber writes them as JSON numbers, which most clients read as floats.
+	DecimalFormatNumber DecimalFormat = "number"
+	// DecimalFormatString writes them as strings, e.g. "19.99", so they keep their precision.
+	DecimalFormatString DecimalFormat = "string"
+)
+
+// SerializersFileTemplateModel is a slice's response serializers, which write out the documented properties of a
+// response body and nothing else, under their names in the spec.
+type SerializersFileTemplateModel struct {
+	AppName   string
+	SliceName string
+	Decimals  DecimalFormat
+	// Serializers are the component schemas' serializers, which the responses' refer to.
+	Serializers []SerializerTemplateModel
+	// Responses are the serializers of each operation's response body, named after the operation.
+	Responses []SerializerTemplateModel
+}
+
+type SerializerTemplateModel struct {
+	SerializerName string
+	// Properties are the properties of an object schema. Schemas that aren't objects have a Value instead, which
+	// says how to write them as a property without a name would.
+	Properties []SerializerPropertyTemplateModel
+	Value      *SerializerPropertyTemplateModel
+	// Extensions are the schema's, or operation's, vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+type SerializerPropertyTemplateModel struct {
+	// Name is the property's name in the spec, and so in the JSON, e.g. profileImageUrl, and Key is the Ruby symbol
+	// services call it, e.g. :profile_image_url, see Generator.serviceKey. They're empty for a schema's Value.
+	Name string
+	Key  string
+	// Type is how the value's written, see serializerType, and Properties are those of an inline object, for which
+	// Type is empty.
+	Type       string
+	Properties []SerializerPropertyTemplateModel
+	// Array is set for arrays, whose items are written as Type or Properties say.
+	Array bool
+}
+
+// GenerateSerializersFileTemplateModels generates a serializers file for each slice.
+func (g Generator) GenerateSerializersFileTemplateModels() ([]SerializersFileTemplateModel, error) {
+	var serializersFileTemplateModels []SerializersFileTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		serializersFileTemplateModels = append(serializersFileTemplateModels, g.GenerateSerializersFileTemplateModel(sliceName))
+	}
+
+	return serializersFileTemplateModels, nil
+}
+
+// GenerateSerializersFileTemplateModel generates serializers for the component schemas and for the responses of
+// the operations in a slice.
+func (g Generator) GenerateSerializersFileTemplateModel(sliceName string) SerializersFileTemplateModel {
+	serializersFileTemplateModel := SerializersFileTemplateModel{AppName: g.AppName, SliceName: sliceName, Decimals: g.Decimals}
+
+	// serializers are constants, so one can only refer to another that's been assigned already, and any others,
+	// which come of cycles, are looked up lazily
+	defined := map[string]bool{}
+	for _, schemaName := range schemaDefinitionOrder(g.Schemas) {
+		schemaRef := g.Schemas[schemaName]
+		schemaExtensions, _ := readSchemaExtensions(schemaRef.Value.Extensions)
+		if schemaExtensions.Skip {
+			continue
+		}
+
+		serializerTemplateModel := SerializerTemplateModel{
+			SerializerName: schemaName,
+			Extensions:     decodedExtensions(schemaRef.Value.Extensions),
+		}
+		if schemaRef.Value.Type == "object" || len(schemaRef.Value.Properties) > 0 {
+			serializerTemplateModel.Properties = g.serializerProperties(schemaRef, defined)
+		} else if value, ok := g.serializerProperty("", schemaRef, defined); ok {
+			serializerTemplateModel.Value = &value
+		}
+		defined[schemaName] = true
+
+		serializersFileTemplateModel.Serializers = append(serializersFileTemplateModel.Serializers, serializerTemplateModel)
+	}
+
+	for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+		serializersFileTemplateModel.Responses = append(serializersFileTemplateModel.Responses, SerializerTemplateModel{
+			SerializerName: operationDefinition.OperationId,
+			// like the response contract, only objects have properties to write
+			Properties: g.serializerProperties(operationDefinition.ResponseBody200Schema, defined),
+			Extensions: decodedExtensions(operationDefinition.Spec.Extensions),
+		})
+	}
+
+	return serializersFileTemplateModel
+}
+
+// responseSerializerName is the name of the serializer an operation's action writes its response body with, or
+// empty if serializers aren't being generated and it writes out the validated response as it is.
+func (g Generator) responseSerializerName(operationDefinition OperationDefinition) string {
+	if !g.artifacts().Includes(ArtifactSerializers) {
+		return ""
+	}
+
+	return operationDefinition.OperationId
+}
+
+// serializerProperties are the properties of an object schema to write, in the same order, and leaving out the
+// same ones, as its attributes in schemas.rb.
+func (g Generator) serializerProperties(schemaRef *openapi3.SchemaRef, defined map[string]bool) []SerializerPropertyTemplateModel {
+	if schemaRef == nil {
+		return nil
+	}
+
+	var properties []SerializerPropertyTemplateModel
+	for _, propertyName := range sortedSchemaRefPropertyKeys(schemaRef) {
+		if property, ok := g.serializerProperty(propertyName, schemaRef.Value.Properties[propertyName], defined); ok {
+			properties = append(properties, property)
+		}
+	}
+
+	return properties
+}
+
+// serializerProperty is how to write a property, or reports false if it's left out of schemas.rb, and so out of the
+// validated response too.
+func (g Generator) serializerProperty(name string, schemaRef *openapi3.SchemaRef, defined map[string]bool) (SerializerPropertyTemplateModel, bool) {
+	if _, ok := g.generateAttributeDefinition(name, schemaRef, false); !ok {
+		return SerializerPropertyTemplateModel{}, false
+	}
+
+	property := SerializerPropertyTemplateModel{Name: name}
+	if name != "" {
+		property.Key = ":" + rubySymbolKey(g.serviceKey(name))
+	}
+	if schemaRef.Value.Type == "array" && schemaRef.Value.Items != nil {
+		property.Array = true
+		schemaRef = schemaRef.Value.Items
+	}
+
+	switch {
+	case strings.HasPrefix(schemaRef.Ref, componentSchemasRefPrefix):
+		property.Type = strings.TrimPrefix(schemaRef.Ref, componentSchemasRefPrefix)
+		if !defined[property.Type] {
+			property.Type = "-> { " + property.Type + " }"
+		}
+	case len(schemaRef.Value.Properties) > 0:
+		property.Properties = g.serializerProperties(schemaRef, defined)
+	default:
+		property.Type = serializerType(schemaRef.Value)
+	}
+
+	return property, true
+}
+
+// serializerType is the symbol for how a value is written, going by its schema's type and format, e.g. :iso8601
+// for dates and times. See the Serializers module in serializers.rb.tmpl.
+func serializerType(schema *openapi3.Schema) string {
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date" || schema.Format == "date-time" {
+			return ":iso8601"
+		}
+		return ":string"
+	case "integer":
+		return ":integer"
+	case "number":
+		return ":number"
+	case "boolean":
+		return ":boolean"
+	default:
+		// free-form objects, and schemas without a type, have nothing documented to hold them to
+		return ":value"
+	}
+}
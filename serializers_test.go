@@ -0,0 +1,99 @@
+package main
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerator_GenerateSerializersFileTemplateModel(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_factories.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	serializersFileTemplateModel := g.GenerateSerializersFileTemplateModel("API")
+
+	role, user, team := serializersFileTemplateModel.Serializers[0], serializersFileTemplateModel.Serializers[1], serializersFileTemplateModel.Serializers[2]
+	assert.Equal(t, &SerializerPropertyTemplateModel{Type: ":string"}, role.Value)
+
+	// tokens are skipped, and Team isn't assigned yet, so it's looked up when it's needed
+	assert.Equal(t, []SerializerPropertyTemplateModel{
+		{Name: "createdAt", Key: ":created_at", Type: ":iso8601"},
+		{Name: "email", Key: ":email", Type: ":string"},
+		{Name: "id", Key: ":id", Type: ":string"},
+		{Name: "locale", Key: ":locale", Type: ":string"},
+		{Name: "role", Key: ":role", Type: "Role"},
+		{Name: "team", Key: ":team", Type: "-> { Team }"},
+	}, user.Properties)
+
+	assert.Equal(t, []SerializerPropertyTemplateModel{
+		{Name: "members", Key: ":members", Type: "User", Array: true},
+		{Name: "name", Key: ":name", Type: ":string"},
+		{Name: "parent", Key: ":parent", Type: "-> { Team }"},
+	}, team.Properties)
+
+	assert.Len(t, serializersFileTemplateModel.Responses, 1)
+	assert.Equal(t, "ListUsers", serializersFileTemplateModel.Responses[0].SerializerName)
+}
+
+func TestGenerator_GenerateSerializersFileTemplateModel_InlineObjects(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	serializersFileTemplateModel := g.GenerateSerializersFileTemplateModel("API")
+
+	var getBookById SerializerTemplateModel
+	for _, response := range serializersFileTemplateModel.Responses {
+		if response.SerializerName == "GetBookById" {
+			getBookById = response
+		}
+	}
+
+	assert.Contains(t, getBookById.Properties, SerializerPropertyTemplateModel{
+		Name: "avatar",
+		Key:  ":avatar",
+		Properties: []SerializerPropertyTemplateModel{
+			{Name: "id", Key: ":id", Type: ":integer"},
+			{Name: "profileImageUrl", Key: ":profile_image_url", Type: ":string"},
+		},
+	})
+}
+
+func TestWriter_ExecuteSerializersFileTemplate_Decimals(t *testing.T) {
+	w, err := NewWriter("gen", "TestApp", "", LayoutFlat)
+	if err != nil {
+		t.Fatalf("error creating writer: %s\n", err)
+	}
+
+	serializers, err := w.ExecuteSerializersFileTemplate(SerializersFileTemplateModel{SliceName: "API", Decimals: DecimalFormatString})
+	assert.NoError(t, err)
+	assert.Contains(t, serializers.String(), `value.is_a?(BigDecimal) ? value.to_s("F") : value`)
+
+	serializers, err = w.ExecuteSerializersFileTemplate(SerializersFileTemplateModel{SliceName: "API", Decimals: DecimalFormatNumber})
+	assert.NoError(t, err)
+	assert.Contains(t, serializers.String(), `value.is_a?(BigDecimal) ? value.to_f : value`)
+}
+
+func Test_serializerType(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *openapi3.Schema
+		want   string
+	}{
+		{"string", &openapi3.Schema{Type: "string"}, ":string"},
+		{"date", &openapi3.Schema{Type: "string", Format: "date"}, ":iso8601"},
+		{"date-time", &openapi3.Schema{Type: "string", Format: "date-time"}, ":iso8601"},
+		{"integer", &openapi3.Schema{Type: "integer"}, ":integer"},
+		{"number", &openapi3.Schema{Type: "number", Format: "double"}, ":number"},
+		{"boolean", &openapi3.Schema{Type: "boolean"}, ":boolean"},
+		{"free-form object", &openapi3.Schema{Type: "object"}, ":value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, serializerType(tt.schema))
+		})
+	}
+}
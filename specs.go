@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/deepmap/oapi-codegen/pkg/codegen"
+	"github.com/getkin/kin-openapi/openapi3"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SpecMode decides how operations from several input specs are combined.
+type SpecMode string
+
+const (
+	// SpecModeMerge puts every spec's operations into the same slices, as if they were one spec.
+	SpecModeMerge SpecMode = "merge"
+	// SpecModeSlicePerSpec gives each spec its own slice, named by an x-hanami-slice extension at the root of
+	// the spec, or after the spec's file name.
+	SpecModeSlicePerSpec SpecMode = "slice-per-spec"
+)
+
+// expandInputs turns input paths, which can be globs, into the list of spec files to load.
+func expandInputs(inputs []string) ([]string, error) {
+	var inputFiles []string
+	seen := map[string]bool{}
+
+	for _, input := range inputs {
+		matches := []string{input}
+		if strings.ContainsAny(input, "*?[") {
+			var err error
+			matches, err = filepath.Glob(input)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input glob %q: %w", input, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("input glob %q doesn't match any files", input)
+			}
+		}
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				inputFiles = append(inputFiles, match)
+			}
+		}
+	}
+
+	return inputFiles, nil
+}
+
+// specSliceName is the slice a spec's operations go into in SpecModeSlicePerSpec.
+func specSliceName(inputFile string, swagger *openapi3.T) (string, error) {
+	sliceName, err := stringExtension(swagger.Extensions, ExtensionSlice)
+	if err != nil {
+		return "", err
+	}
+
+	if sliceName == "" {
+		baseName := filepath.Base(inputFile)
+		sliceName = codegen.ToCamelCase(strings.TrimSuffix(baseName, filepath.Ext(baseName)))
+	}
+
+	if !rubyConstantRegex.MatchString(sliceName) {
+		return "", fmt.Errorf("slice name %q for spec %s must be a valid Ruby constant, set %s at the root of the spec to pick one", sliceName, inputFile, ExtensionSlice)
+	}
+
+	return sliceName, nil
+}
+
+// mergeSchemas adds the component schemas from the spec at inputFile to merged. The same schema defined
+// identically in several specs is fine, but two different schemas with the same name are reported.
+func mergeSchemas(merged openapi3.Schemas, schemaSources map[string]string, inputFile string, schemas openapi3.Schemas) error {
+	var errs []error
+
+	for _, name := range sortedKeys(schemas) {
+		schema := schemas[name]
+		existing, ok := merged[name]
+		if !ok {
+			merged[name] = schema
+			schemaSources[name] = inputFile
+			continue
+		}
+
+		same, err := sameSchema(existing, schema)
+		if err != nil {
+			return err
+		}
+		if !same {
+			errs = append(errs, fmt.Errorf("schema %s is defined differently in %s and %s", name, schemaSources[name], inputFile))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func sameSchema(a *openapi3.SchemaRef, b *openapi3.SchemaRef) (bool, error) {
+	aJson, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bJson, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	return string(aJson) == string(bJson), nil
+}
+
+var pathParamRegex = regexp.MustCompile("{(.*?)}")
+
+// findOperationCollisions reports operations that would clobber each other once generated: two operations
+// with the same operationId in one slice, two with the same action in one slice, e.g. from the same x-hanami-action,
+// or two operations on the same route in one slice.
+func findOperationCollisions(operationDefinitions []OperationDefinition) error {
+	var errs []error
+	operationIds := map[string]OperationDefinition{}
+	actions := map[string]OperationDefinition{}
+	routes := map[string]OperationDefinition{}
+
+	for _, operationDefinition := range operationDefinitions {
+		operationIdKey := operationDefinition.SliceName + " " + operationDefinition.OperationId
+		other, operationIdCollides := operationIds[operationIdKey]
+		if operationIdCollides {
+			errs = append(errs, fmt.Errorf(
+				"operationId %s in slice %s is used by both %s (%s) and %s (%s)",
+				operationDefinition.OperationId, operationDefinition.SliceName,
+				describeOperation(other), other.SpecPath,
+				describeOperation(operationDefinition), operationDefinition.SpecPath,
+			))
+		}
+		operationIds[operationIdKey] = operationDefinition
+
+		// the action's file and container key, e.g. books.show
+		action := moduleKey(operationDefinition.ModuleName) + "." + toSnake(operationDefinition.ActionName)
+		actionKey := operationDefinition.SliceName + " " + action
+		// operations with the same operationId have the same action too, which goes without saying
+		if other, ok := actions[actionKey]; ok && !operationIdCollides {
+			errs = append(errs, fmt.Errorf(
+				"action %s in slice %s is used by both %s (%s) and %s (%s)",
+				action, operationDefinition.SliceName,
+				describeOperation(other), other.SpecPath,
+				describeOperation(operationDefinition), operationDefinition.SpecPath,
+			))
+		}
+		actions[actionKey] = operationDefinition
+
+		// path params match anything, whatever they're called
+		routeKey := operationDefinition.SliceName + " " + operationDefinition.Method + " " + pathParamRegex.ReplaceAllString(operationDefinition.Path, "{}")
+		if other, ok := routes[routeKey]; ok {
+			errs = append(errs, fmt.Errorf(
+				"route %s in slice %s is defined by both %s (%s) and %s (%s)",
+				describeOperation(operationDefinition), operationDefinition.SliceName,
+				other.OperationId, other.SpecPath,
+				operationDefinition.OperationId, operationDefinition.SpecPath,
+			))
+		}
+		routes[routeKey] = operationDefinition
+	}
+
+	return errors.Join(errs...)
+}
+
+func describeOperation(operationDefinition OperationDefinition) string {
+	return operationDefinition.Method + " " + operationDefinition.Path
+}
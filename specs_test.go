@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewGeneratorFromConfig_MergedSpecs(t *testing.T) {
+	g, err := NewGeneratorFromConfig(&Config{
+		Inputs:    []string{"fixtures/specs/*.yaml"},
+		AppName:   "TestApp",
+		SliceName: "API",
+		SpecMode:  SpecModeMerge,
+		Layout:    LayoutHanami,
+	})
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	// x-hanami-slice at the root of a spec only matters in slice-per-spec mode
+	assert.Equal(t, []string{"API"}, g.SliceNames())
+	assert.Len(t, g.OperationDefinitions, 2)
+	assert.Contains(t, g.Schemas, "Money")
+}
+
+func TestNewGeneratorFromConfig_SlicePerSpec(t *testing.T) {
+	g, err := NewGeneratorFromConfig(&Config{
+		Inputs:    []string{"fixtures/specs/books.yaml", "fixtures/specs/authors.yaml"},
+		AppName:   "TestApp",
+		SliceName: "API",
+		SpecMode:  SpecModeSlicePerSpec,
+		Layout:    LayoutHanami,
+	})
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	assert.Equal(t, []string{"Books", "People"}, g.SliceNames())
+
+	model, err := g.GenerateRoutesFileTemplateModel()
+	if err != nil {
+		t.Fatalf("error generating routes template model: %s\n", err)
+	}
+	assert.Len(t, model.Slices, 2)
+	assert.Equal(t, "GetBooks", model.Slices[0].Routes[0].OperationName)
+	assert.Equal(t, "GetAuthors", model.Slices[1].Routes[0].OperationName)
+}
+
+func TestNewGeneratorFromConfig_CollidingSpecs(t *testing.T) {
+	_, err := NewGeneratorFromConfig(&Config{
+		Inputs:    []string{"fixtures/specs_colliding/*.yaml"},
+		AppName:   "TestApp",
+		SliceName: "API",
+		SpecMode:  SpecModeMerge,
+	})
+
+	books := filepath.Join("fixtures", "specs_colliding", "books.yaml")
+	library := filepath.Join("fixtures", "specs_colliding", "library.yaml")
+	assert.ErrorContains(t, err, "schema Money is defined differently in "+books+" and "+library)
+	assert.ErrorContains(t, err, "operationId GetBook in slice API is used by both GET /books/{bookId} ("+books+") and GET /books/{id} ("+library+")")
+	assert.ErrorContains(t, err, "route GET /books/{id} in slice API is defined by both GetBook ("+books+") and GetBook ("+library+")")
+}
+
+func TestExpandInputs_NoMatches(t *testing.T) {
+	_, err := expandInputs([]string{"fixtures/nothing_here/*.yaml"})
+	assert.ErrorContains(t, err, `input glob "fixtures/nothing_here/*.yaml" doesn't match any files`)
+}
+
+func TestNewGeneratorFromConfig_CollidingActions(t *testing.T) {
+	_, err := NewGenerator("fixtures/test_spec_duplicate_actions.yaml", "TestApp", "API")
+
+	assert.ErrorContains(t, err, "action books.show in slice API is used by both GET /books/featured (fixtures/test_spec_duplicate_actions.yaml) and GET /books/{bookId} (fixtures/test_spec_duplicate_actions.yaml)")
+}
@@ -0,0 +1,153 @@
+package main
+
+import "strings"
+
+// StructsFileTemplateModel is a slice's Dry::Struct value objects: one for each component schema, and one for each
+// operation's request and response, so services can work with typed objects rather than hashes.
+type StructsFileTemplateModel struct {
+	AppName   string
+	SliceName string
+	Structs   []StructTemplateModel
+	// Requests and Responses are the structs of each operation's request, which its action builds from the
+	// validated params and hands to its service, and of its response, for services to return.
+	Requests  []StructTemplateModel
+	Responses []StructTemplateModel
+}
+
+type StructTemplateModel struct {
+	// StructName is the struct's constant, e.g. Pet, or GetPetById for an operation's request or response.
+	StructName string
+	Attributes []AttributeDefinition
+	// Type is a dry-types type for schemas that aren't objects, e.g. `Types::Array.of(Pet)`, which are defined as a
+	// type rather than a struct. It's empty for ones that are.
+	Type string
+	// Extensions are the schema's, or operation's, vendor extensions, see decodedExtensions.
+	Extensions map[string]any
+}
+
+// GenerateStructsFileTemplateModels generates a structs file for each slice.
+func (g Generator) GenerateStructsFileTemplateModels() ([]StructsFileTemplateModel, error) {
+	var structsFileTemplateModels []StructsFileTemplateModel
+	for _, sliceName := range g.SliceNames() {
+		structsFileTemplateModels = append(structsFileTemplateModels, g.GenerateStructsFileTemplateModel(sliceName))
+	}
+
+	return structsFileTemplateModels, nil
+}
+
+// GenerateStructsFileTemplateModel generates the structs for the component schemas, which are the same as the
+// ones in schemas.rb, and for the requests and responses of the operations in a slice.
+func (g Generator) GenerateStructsFileTemplateModel(sliceName string) StructsFileTemplateModel {
+	structsFileTemplateModel := StructsFileTemplateModel{AppName: g.AppName, SliceName: sliceName}
+
+	// a struct can only refer to ones defined before it, which schemaDefinitionOrder sees to, bar cycles
+	defined := map[string]bool{}
+	for _, schemaName := range schemaDefinitionOrder(g.Schemas) {
+		schemaRef := g.Schemas[schemaName]
+		schemaExtensions, _ := readSchemaExtensions(schemaRef.Value.Extensions)
+		if schemaExtensions.Skip {
+			continue
+		}
+		defined[schemaName] = true
+
+		structTemplateModel := StructTemplateModel{
+			StructName: schemaName,
+			Extensions: decodedExtensions(schemaRef.Value.Extensions),
+		}
+		if schemaRef.Value.Type == "object" || len(schemaRef.Value.Properties) > 0 {
+			structTemplateModel.Attributes = undefinedReferencesToHashes(g.generateAttributeDefinitions(schemaRef), defined)
+		} else if attributeDefinition, ok := g.generateAttributeDefinition(schemaName, schemaRef, true); ok {
+			structTemplateModel.Type = structType(undefinedReferencesToHashes([]AttributeDefinition{attributeDefinition}, defined)[0])
+		}
+
+		structsFileTemplateModel.Structs = append(structsFileTemplateModel.Structs, structTemplateModel)
+	}
+
+	for _, operationDefinition := range g.operationDefinitionsInSlice(sliceName) {
+		extensions := decodedExtensions(operationDefinition.Spec.Extensions)
+
+		structsFileTemplateModel.Requests = append(structsFileTemplateModel.Requests, StructTemplateModel{
+			StructName: operationDefinition.OperationId,
+			Attributes: g.requestAttributeDefinitions(operationDefinition),
+			Extensions: extensions,
+		})
+
+		// an empty response struct would be no use to anyone
+		if responseAttributes := g.generateAttributeDefinitions(operationDefinition.ResponseBody200Schema); len(responseAttributes) > 0 {
+			structsFileTemplateModel.Responses = append(structsFileTemplateModel.Responses, StructTemplateModel{
+				StructName: operationDefinition.OperationId,
+				Attributes: responseAttributes,
+				Extensions: extensions,
+			})
+		}
+	}
+
+	return structsFileTemplateModel
+}
+
+// requestStructName is the name of the struct an operation's action hands its service, or empty if the action hands
+// it a hash of the params: unless RequestStructs is set, since services written against a hash would break, when
+// structs aren't being generated, or when the service is an existing one, which won't be expecting a struct.
+func (g Generator) requestStructName(operationDefinition OperationDefinition) string {
+	if !g.RequestStructs || !g.artifacts().Includes(ArtifactStructs) || operationDefinition.HanamiExtensions.Service != "" {
+		return ""
+	}
+
+	return operationDefinition.OperationId
+}
+
+// undefinedReferencesToHashes swaps references to component schemas that aren't defined yet, which only happens in
+// a cycle, for plain hashes, since Ruby would otherwise fail on the missing constant.
+func undefinedReferencesToHashes(attributeDefinitions []AttributeDefinition, defined map[string]bool) []AttributeDefinition {
+	var swapped []AttributeDefinition
+	for _, attributeDefinition := range attributeDefinitions {
+		if schemaName, ok := strings.CutPrefix(attributeDefinition.AttributeType, "Schemas::"); ok && !defined[schemaName] {
+			attributeDefinition.AttributeType = ":hash"
+		}
+		attributeDefinition.NestedAttributes = undefinedReferencesToHashes(attributeDefinition.NestedAttributes, defined)
+		swapped = append(swapped, attributeDefinition)
+	}
+
+	return swapped
+}
+
+// structTypes maps the dry-schema types attributes are defined with onto dry-types' strict types. Params have been
+// coerced by the contracts by the time they're made into structs, so there's nothing left to coerce.
+var structTypes = map[string]string{
+	":string":    "Types::String",
+	":integer":   "Types::Integer",
+	":float":     "Types::Float",
+	":decimal":   "Types::Decimal",
+	":bool":      "Types::Bool",
+	":date":      "Types::Date",
+	":date_time": "Types::DateTime",
+	":time":      "Types::Time",
+	":uuid_v4?":  "Types::String",
+	":hash":      "Types::Hash",
+}
+
+// structType is the dry-types type of an attribute without nested attributes, e.g. `Types::Array.of(Pet)`.
+// References to component schemas are to their structs, and x-hanami-ruby-type types are used as they are.
+func structType(attributeDefinition AttributeDefinition) string {
+	attributeType := attributeDefinition.AttributeType
+	if schemaName, ok := strings.CutPrefix(attributeType, "Schemas::"); ok {
+		attributeType = schemaName
+	} else if structType, ok := structTypes[attributeType]; ok {
+		attributeType = structType
+	} else if strings.HasPrefix(attributeType, ":") || attributeType == "" {
+		// a typeMappings type, or predicate, that there's no dry-types type for
+		attributeType = "Types::Any"
+	}
+
+	switch attributeDefinition.Verb {
+	case "array":
+		if attributeDefinition.HasChildren {
+			attributeType = "Types::Hash"
+		}
+		return "Types::Array.of(" + attributeType + ")"
+	case "maybe":
+		return attributeType + ".optional"
+	default:
+		return attributeType
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerator_GenerateStructsFileTemplateModel(t *testing.T) {
+	g, err := NewGenerator("fixtures/test_spec_factories.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	structsFileTemplateModel := g.GenerateStructsFileTemplateModel("API")
+
+	var structNames []string
+	for _, structTemplateModel := range structsFileTemplateModel.Structs {
+		structNames = append(structNames, structTemplateModel.StructName)
+	}
+	assert.Equal(t, []string{"Role", "User", "Team"}, structNames)
+
+	role, user, team := structsFileTemplateModel.Structs[0], structsFileTemplateModel.Structs[1], structsFileTemplateModel.Structs[2]
+	assert.Equal(t, "Types::String", role.Type)
+	assert.Empty(t, role.Attributes)
+
+	userTypes := map[string]string{}
+	for _, attributeDefinition := range user.Attributes {
+		userTypes[attributeDefinition.AttributeName] = structType(attributeDefinition)
+	}
+	// Team comes after User, so User can only have it as a hash
+	assert.Equal(t, map[string]string{
+		"createdAt": "Types::String",
+		"email":     "Types::String",
+		"id":        "Types::String",
+		"locale":    "Types::String",
+		"role":      "Role",
+		"team":      "Types::Hash",
+	}, userTypes)
+
+	teamTypes := map[string]string{}
+	for _, attributeDefinition := range team.Attributes {
+		teamTypes[attributeDefinition.AttributeName] = structType(attributeDefinition)
+	}
+	assert.Equal(t, map[string]string{
+		"members": "Types::Array.of(User)",
+		"name":    "Types::String",
+		"parent":  "Team",
+	}, teamTypes)
+
+	assert.Len(t, structsFileTemplateModel.Requests, 1)
+	assert.Equal(t, "ListUsers", structsFileTemplateModel.Requests[0].StructName)
+	// the response is an array, which has no attributes to make a struct of
+	assert.Empty(t, structsFileTemplateModel.Responses)
+}
+
+func TestGenerator_GenerateActionTemplateModels_RequestStructs(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec.yaml"
+	config.RequestStructs = true
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+
+	for _, actionTemplateModel := range actionTemplateModels {
+		assert.Equal(t, actionTemplateModel.ActionName, actionTemplateModel.RequestStruct)
+	}
+}
+
+func TestGenerator_GenerateActionTemplateModels_WithoutStructs(t *testing.T) {
+	config := defaultConfig()
+	config.Input = "fixtures/test_spec.yaml"
+	config.Exclude = []string{"structs"}
+	config.RequestStructs = true
+
+	g, err := NewGeneratorFromConfig(config)
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	actionTemplateModels, err := g.GenerateActionTemplateModels()
+	if err != nil {
+		t.Fatalf("error generating action template models: %s\n", err)
+	}
+
+	for _, actionTemplateModel := range actionTemplateModels {
+		assert.Empty(t, actionTemplateModel.RequestStruct)
+	}
+}
+
+func Test_structType(t *testing.T) {
+	tests := []struct {
+		name                string
+		attributeDefinition AttributeDefinition
+		want                string
+	}{
+		{"scalar", AttributeDefinition{AttributeType: ":integer", Verb: "value"}, "Types::Integer"},
+		{"nullable", AttributeDefinition{AttributeType: ":string", Verb: "maybe"}, "Types::String.optional"},
+		{"uuid", AttributeDefinition{AttributeType: ":uuid_v4?", Verb: "value"}, "Types::String"},
+		{"reference", AttributeDefinition{AttributeType: "Schemas::Pet"}, "Pet"},
+		{"array of references", AttributeDefinition{AttributeType: "Schemas::Pet", Verb: "array"}, "Types::Array.of(Pet)"},
+		{"array of objects", AttributeDefinition{AttributeType: ":hash", Verb: "array", HasChildren: true}, "Types::Array.of(Types::Hash)"},
+		{"ruby type", AttributeDefinition{AttributeType: "Types::Money", Verb: "value"}, "Types::Money"},
+		{"unknown mapping", AttributeDefinition{AttributeType: ":str?", Verb: "value"}, "Types::Any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, structType(tt.attributeDefinition))
+		})
+	}
+}
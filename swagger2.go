@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isSwagger2 reports whether data, YAML or JSON, is a Swagger 2.0 document rather than an OpenAPI 3 one.
+func isSwagger2(data []byte) bool {
+	var header struct {
+		Swagger string `json:"swagger"`
+	}
+	err := yaml.Unmarshal(data, &header)
+	return err == nil && header.Swagger == "2.0"
+}
+
+// convertSwagger2 converts a Swagger 2.0 document to OpenAPI 3 with openapi2conv, and returns warnings for
+// anything that doesn't carry over cleanly, see swagger2ConversionWarnings.
+//
+// Swagger 2.0 has no default for consumes, but openapi2conv drops the schema of a body parameter if there isn't
+// one, so specs that leave it out are taken to consume application/json, which is what produces defaults to.
+func convertSwagger2(filePath string, data []byte) (*openapi3.T, []Diagnostic, error) {
+	var doc2 openapi2.T
+	err := yaml.Unmarshal(data, &doc2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing Swagger 2.0 spec: %w", err)
+	}
+
+	warnings := swagger2ConversionWarnings(filePath, &doc2)
+
+	if len(doc2.Consumes) == 0 {
+		doc2.Consumes = []string{MediaTypeJson}
+	}
+
+	swagger, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error converting Swagger 2.0 spec to OpenAPI 3: %w", err)
+	}
+
+	return swagger, warnings, nil
+}
+
+// swagger2ConversionWarnings lists the parts of doc2 that change meaning, or are lost, on the way to OpenAPI 3.
+func swagger2ConversionWarnings(filePath string, doc2 *openapi2.T) []Diagnostic {
+	var warnings []Diagnostic
+
+	for _, name := range sortedKeys(doc2.Parameters) {
+		warnings = append(warnings, swagger2ParameterWarnings(filePath, jsonPointer("parameters", name), doc2.Parameters[name])...)
+	}
+
+	for _, requestPath := range sortedKeys(doc2.Paths) {
+		pathItem := doc2.Paths[requestPath]
+		for i, parameter := range pathItem.Parameters {
+			warnings = append(warnings, swagger2ParameterWarnings(filePath, jsonPointer("paths", requestPath, "parameters", strconv.Itoa(i)), parameter)...)
+		}
+
+		operations := pathItem.Operations()
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			operation := operations[method]
+			operationPointer := jsonPointer("paths", requestPath, strings.ToLower(method))
+
+			hasBody := false
+			for i, parameter := range operation.Parameters {
+				warnings = append(warnings, swagger2ParameterWarnings(filePath, operationPointer+jsonPointer("parameters", strconv.Itoa(i)), parameter)...)
+				hasBody = hasBody || parameter.In == "body" || parameter.In == "formData"
+			}
+
+			if hasBody && len(operation.Consumes) == 0 && len(doc2.Consumes) == 0 {
+				warnings = append(warnings, Diagnostic{filePath, operationPointer, fmt.Sprintf("no consumes given, so the request body is taken to be %s", MediaTypeJson)})
+			}
+		}
+	}
+
+	return warnings
+}
+
+func swagger2ParameterWarnings(filePath string, pointer string, parameter *openapi2.Parameter) []Diagnostic {
+	// refs are warned about where they're defined
+	if parameter == nil || parameter.Ref != "" {
+		return nil
+	}
+
+	var warnings []Diagnostic
+
+	if parameter.In == "formData" {
+		warnings = append(warnings, Diagnostic{filePath, pointer, fmt.Sprintf("formData parameter %q is converted into a property of the request body", parameter.Name)})
+	}
+	if parameter.Type == "file" {
+		warnings = append(warnings, Diagnostic{filePath, pointer, fmt.Sprintf("file parameter %q is converted into a binary string", parameter.Name)})
+	}
+	if parameter.CollectionFormat != "" && parameter.CollectionFormat != "csv" {
+		warnings = append(warnings, Diagnostic{filePath, pointer, fmt.Sprintf("collectionFormat %q of parameter %q is dropped, so it's read as csv", parameter.CollectionFormat, parameter.Name)})
+	}
+
+	return warnings
+}
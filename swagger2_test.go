@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadSwagger_Swagger2(t *testing.T) {
+	swagger, warnings, err := loadSwagger("fixtures/swagger2/petstore.yaml")
+	if err != nil {
+		t.Fatalf("error loading spec: %s\n", err)
+	}
+
+	assert.Equal(t, "3.0.3", swagger.OpenAPI)
+	assert.Contains(t, swagger.Components.Schemas, "Pet")
+	assert.Equal(t, "#/components/schemas/Pet", swagger.Paths["/pets"].Post.RequestBody.Value.GetMediaType(MediaTypeJson).Schema.Ref)
+
+	assert.Equal(t, []Diagnostic{
+		{"fixtures/swagger2/petstore.yaml", "/paths/~1pets/get/parameters/0", "collectionFormat \"pipes\" of parameter \"ids\" is dropped, so it's read as csv"},
+		{"fixtures/swagger2/petstore.yaml", "/paths/~1pets/post", "no consumes given, so the request body is taken to be application/json"},
+		{"fixtures/swagger2/petstore.yaml", "/paths/~1pets~1{petId}~1photo/post/parameters/1", "formData parameter \"caption\" is converted into a property of the request body"},
+		{"fixtures/swagger2/petstore.yaml", "/paths/~1pets~1{petId}~1photo/post/parameters/2", "formData parameter \"photo\" is converted into a property of the request body"},
+		{"fixtures/swagger2/petstore.yaml", "/paths/~1pets~1{petId}~1photo/post/parameters/2", "file parameter \"photo\" is converted into a binary string"},
+	}, warnings)
+}
+
+func TestGenerator_Swagger2(t *testing.T) {
+	g, err := NewGenerator("fixtures/swagger2/petstore.yaml", "TestApp", "API")
+	if err != nil {
+		t.Fatalf("error creating generator: %s\n", err)
+	}
+
+	assert.Len(t, g.Warnings, 5)
+
+	model, err := g.GenerateContractsFileTemplateModel("API")
+	if err != nil {
+		t.Fatalf("error generating contracts file: %s\n", err)
+	}
+
+	// UploadPhotoRequestContract, with the formData params as body properties
+	contract := model.Contracts[4]
+	assert.Equal(t, "UploadPhotoRequestContract", contract.ContractName)
+	var attributeNames []string
+	for _, attribute := range contract.Attributes {
+		attributeNames = append(attributeNames, attribute.AttributeName)
+	}
+	assert.ElementsMatch(t, []string{"caption", "photo", "petId"}, attributeNames)
+}
+
+func Test_jsonPointer(t *testing.T) {
+	assert.Equal(t, "/paths/~1books~1{id}/get", jsonPointer("paths", "/books/{id}", "get"))
+	assert.Equal(t, "/components/schemas/a~0b", jsonPointer("components", "schemas", "a~b"))
+}